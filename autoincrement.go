@@ -0,0 +1,146 @@
+package pg2mysql
+
+import "fmt"
+
+// ownedSequence is a PostgreSQL sequence that generates the values of a
+// serial or identity column.
+type ownedSequence struct {
+	table     string
+	column    string
+	lastValue int64
+	increment int64
+}
+
+// readOwnedSequences returns the sequences owned by columns of migrated
+// tables that have generated at least one value.
+func readOwnedSequences(db DB) ([]ownedSequence, error) {
+	rows, err := db.Querier().Query(`
+	SELECT tn.nspname,
+	       t.relname,
+	       a.attname,
+	       ps.last_value,
+	       ps.increment_by
+	FROM   pg_class s
+	       JOIN pg_namespace n
+	         ON n.oid = s.relnamespace
+	       JOIN pg_depend d
+	         ON d.classid = 'pg_class'::regclass
+	            AND d.objid = s.oid
+	            AND d.refclassid = 'pg_class'::regclass
+	            AND d.deptype IN ('a', 'i')
+	       JOIN pg_class t
+	         ON t.oid = d.refobjid
+	       JOIN pg_namespace tn
+	         ON tn.oid = t.relnamespace
+	       JOIN pg_attribute a
+	         ON a.attrelid = t.oid
+	            AND a.attnum = d.refobjsubid
+	       JOIN pg_sequences ps
+	         ON ps.schemaname = n.nspname
+	            AND ps.sequencename = s.relname
+	WHERE  s.relkind = 'S'
+	       AND ps.last_value IS NOT NULL
+	ORDER  BY tn.nspname,
+	          t.relname,
+	          a.attname`)
+	if err != nil {
+		return nil, err
+	}
+
+	var sequences []ownedSequence
+	for rows.Next() {
+		var schema, table string
+		var sequence ownedSequence
+		if err := rows.Scan(&schema, &table, &sequence.column, &sequence.lastValue, &sequence.increment); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		var ok bool
+		sequence.table, ok = db.TableName(schema, table)
+		if !ok {
+			continue
+		}
+
+		sequences = append(sequences, sequence)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sequences, rows.Close()
+}
+
+// readAutoIncrementColumns returns the AUTO_INCREMENT column of each
+// migrated table in MySQL that has one.
+func readAutoIncrementColumns(db DB) (map[string]string, error) {
+	condition, args := mysqlSchemaCondition(db, "table_schema")
+	query := `
+	SELECT table_schema,
+	       table_name,
+	       column_name
+	FROM   information_schema.columns
+	WHERE  ` + condition + `
+	       AND extra LIKE '%auto_increment%'`
+	rows, err := db.Querier().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]string{}
+	for rows.Next() {
+		var schema, tableName, column string
+		if err := rows.Scan(&schema, &tableName, &column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if table, ok := db.TableName(schema, tableName); ok {
+			columns[table] = column
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return columns, rows.Close()
+}
+
+// resetAutoIncrements sets the AUTO_INCREMENT counter of each table of
+// schema to the next value of the sequence owning the matching column in
+// PostgreSQL, so that rows inserted after the migration do not reuse the
+// values of migrated rows. Sequences that count down are left alone, as are
+// columns that are not AUTO_INCREMENT in MySQL.
+func resetAutoIncrements(src, dst DB, schema *Schema, watcher MigratorWatcher) error {
+	sequences, err := readOwnedSequences(src)
+	if err != nil {
+		return fmt.Errorf("failed to read sequences: %s", err)
+	}
+
+	autoIncrementColumns, err := readAutoIncrementColumns(dst)
+	if err != nil {
+		return fmt.Errorf("failed to read auto increment columns: %s", err)
+	}
+
+	for _, sequence := range sequences {
+		if _, ok := schema.Tables[sequence.table]; !ok {
+			continue
+		}
+
+		if autoIncrementColumns[sequence.table] != sequence.column || sequence.increment <= 0 {
+			continue
+		}
+
+		next := sequence.lastValue + sequence.increment
+		_, err := dst.Querier().Exec(fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", dst.TableNameForQuery(sequence.table), next))
+		if err != nil {
+			return fmt.Errorf("failed to reset auto increment of %s: %s", sequence.table, err)
+		}
+
+		watcher.DidResetAutoIncrement(sequence.table, next)
+	}
+
+	return nil
+}
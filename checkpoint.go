@@ -0,0 +1,173 @@
+package pg2mysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Checkpoint records the progress of a migration so that it can be resumed.
+type Checkpoint struct {
+	Tables map[string]*TableCheckpoint `json:"tables"`
+}
+
+// TableCheckpoint records the progress of a single table. Every table has at
+// least one chunk; tables that were not split have a single unbounded chunk.
+type TableCheckpoint struct {
+	Completed bool               `json:"completed"`
+	Chunks    []*ChunkCheckpoint `json:"chunks"`
+}
+
+// ChunkCheckpoint records the range of keys covered by a chunk and, for
+// tables with an integer key, the last key copied within it.
+type ChunkCheckpoint struct {
+	Lower     *int64 `json:"lower,omitempty"`
+	Upper     *int64 `json:"upper,omitempty"`
+	LastKey   *int64 `json:"last_key,omitempty"`
+	Completed bool   `json:"completed"`
+}
+
+// remaining returns the part of the chunk that has not been copied yet.
+func (c *ChunkCheckpoint) remaining() *keyRange {
+	lower := c.Lower
+	if c.LastKey != nil {
+		next := *c.LastKey + 1
+		lower = &next
+	}
+
+	if lower == nil && c.Upper == nil {
+		return nil
+	}
+
+	return &keyRange{Lower: lower, Upper: c.Upper}
+}
+
+type CheckpointStore interface {
+	// Load returns the saved checkpoint, or an empty checkpoint if none has
+	// been saved.
+	Load() (*Checkpoint, error)
+	Save(*Checkpoint) error
+}
+
+func NewFileCheckpointStore(path string) CheckpointStore {
+	return &fileCheckpointStore{path: path}
+}
+
+type fileCheckpointStore struct {
+	path string
+}
+
+func (f *fileCheckpointStore) Load() (*Checkpoint, error) {
+	checkpoint := &Checkpoint{Tables: map[string]*TableCheckpoint{}}
+
+	bs, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %s", err)
+	}
+
+	if err := json.Unmarshal(bs, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %s", err)
+	}
+
+	if checkpoint.Tables == nil {
+		checkpoint.Tables = map[string]*TableCheckpoint{}
+	}
+
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file and renames it into place
+// so that a crash never leaves a partially written checkpoint behind.
+func (f *fileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	bs, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %s", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path))
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %s", err)
+	}
+
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint: %s", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write checkpoint: %s", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save checkpoint: %s", err)
+	}
+
+	return nil
+}
+
+// checkpointer updates a checkpoint shared between workers and saves it
+// after every change.
+type checkpointer struct {
+	mu         sync.Mutex
+	store      CheckpointStore
+	checkpoint *Checkpoint
+}
+
+func (c *checkpointer) table(name string) *TableCheckpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkpoint.Tables[name]
+}
+
+func (c *checkpointer) startTable(name string, ranges []*keyRange) (*TableCheckpoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tc := &TableCheckpoint{}
+	for _, r := range ranges {
+		chunk := &ChunkCheckpoint{}
+		if r != nil {
+			chunk.Lower = r.Lower
+			chunk.Upper = r.Upper
+		}
+		tc.Chunks = append(tc.Chunks, chunk)
+	}
+	c.checkpoint.Tables[name] = tc
+
+	return tc, c.store.Save(c.checkpoint)
+}
+
+func (c *checkpointer) copiedThrough(chunk *ChunkCheckpoint, key int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	chunk.LastKey = &key
+
+	return c.store.Save(c.checkpoint)
+}
+
+func (c *checkpointer) completeChunk(tc *TableCheckpoint, chunk *ChunkCheckpoint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	chunk.Completed = true
+
+	tc.Completed = true
+	for _, chunk := range tc.Chunks {
+		if !chunk.Completed {
+			tc.Completed = false
+			break
+		}
+	}
+
+	return c.store.Save(c.checkpoint)
+}
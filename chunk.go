@@ -0,0 +1,123 @@
+package pg2mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// keyRange is a half-open range [Lower, Upper) of integer keys. A nil bound
+// is unbounded.
+type keyRange struct {
+	Lower, Upper *int64
+}
+
+// Where returns a condition restricting column to the range, along with its
+// arguments. placeholder returns the placeholder for the nth argument,
+// counted from zero.
+func (r *keyRange) Where(column string, placeholder func(n int) string) (string, []interface{}) {
+	if r == nil {
+		return "", nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	if r.Lower != nil {
+		conditions = append(conditions, fmt.Sprintf("%s >= %s", column, placeholder(len(args))))
+		args = append(args, *r.Lower)
+	}
+	if r.Upper != nil {
+		conditions = append(conditions, fmt.Sprintf("%s < %s", column, placeholder(len(args))))
+		args = append(args, *r.Upper)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func postgresPlaceholder(offset int) func(int) string {
+	return func(n int) string { return fmt.Sprintf("$%d", offset+n+1) }
+}
+
+func mysqlPlaceholder(int) string { return "?" }
+
+// integerTypes are the source column types that can be split into ranges.
+var integerTypes = map[string]bool{
+	"smallint": true,
+	"integer":  true,
+	"bigint":   true,
+}
+
+// integerKey returns the key column of table if its key is a single integer
+// column, which is required to split it into ranges.
+func integerKey(table *Table) (int, *Column, bool) {
+	indexes, columns, err := table.GetKeyColumns()
+	if err != nil || len(columns) != 1 || !integerTypes[columns[0].Type] {
+		return -1, nil, false
+	}
+
+	return indexes[0], columns[0], true
+}
+
+// planChunks splits table into ranges of its key column holding roughly
+// chunkSize rows each, based on the estimated row count and the smallest
+// and largest key in src. The rows of tables without an estimate are
+// counted instead. A table that is small, has no integer key, or is not to
+// be chunked is returned as a single unbounded range.
+func planChunks(src DB, table *Table, chunkSize int64) ([]*keyRange, error) {
+	unbounded := []*keyRange{nil}
+
+	if chunkSize <= 0 {
+		return unbounded, nil
+	}
+
+	_, keyColumn, ok := integerKey(table)
+	if !ok {
+		return unbounded, nil
+	}
+
+	var estimate int64
+	err := src.Querier().QueryRow("SELECT reltuples::bigint FROM pg_class WHERE oid = $1::regclass", table.Name).Scan(&estimate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate row count of %s: %s", table.Name, err)
+	}
+
+	// reltuples is 0 or -1 for tables that were never analyzed and for
+	// partitioned tables, so count their rows instead
+	if estimate <= 0 {
+		err = src.Querier().QueryRow(fmt.Sprintf("SELECT count(*) FROM %s", table.Name)).Scan(&estimate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count rows of %s: %s", table.Name, err)
+		}
+	}
+
+	if estimate <= chunkSize {
+		return unbounded, nil
+	}
+
+	var min, max sql.NullInt64
+	err = src.Querier().QueryRow(fmt.Sprintf("SELECT min(%s), max(%s) FROM %s", keyColumn.Name, keyColumn.Name, table.Name)).Scan(&min, &max)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find key range of %s: %s", table.Name, err)
+	}
+
+	if !min.Valid || min.Int64 == max.Int64 {
+		return unbounded, nil
+	}
+
+	chunks := (estimate + chunkSize - 1) / chunkSize
+	step := (max.Int64 - min.Int64) / chunks
+	if step < 1 {
+		step = 1
+	}
+
+	var ranges []*keyRange
+	var lower *int64
+	for bound := min.Int64 + step; bound <= max.Int64 && int64(len(ranges)) < chunks-1; bound += step {
+		upper := bound
+		ranges = append(ranges, &keyRange{Lower: lower, Upper: &upper})
+		lower = &upper
+	}
+	ranges = append(ranges, &keyRange{Lower: lower})
+
+	return ranges, nil
+}
@@ -0,0 +1,282 @@
+package pg2mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// UniqueCollision is a group of rows whose values are distinct in
+// PostgreSQL but would be duplicates under a unique index in MySQL, given
+// the collations of its columns. Value is the value the rows share under
+// those collations. RowIDs is only set for tables with a key.
+type UniqueCollision struct {
+	Index    string
+	Value    string
+	RowIDs   []RowKey
+	RowCount int64
+}
+
+func (c UniqueCollision) String() string {
+	if len(c.RowIDs) > 0 {
+		return fmt.Sprintf("%d rows would collide as %q in unique index %s: IDs %v", c.RowCount, c.Value, c.Index, c.RowIDs)
+	}
+	return fmt.Sprintf("%d rows would collide as %q in unique index %s", c.RowCount, c.Value, c.Index)
+}
+
+type uniqueIndex struct {
+	name    string
+	columns []uniqueIndexColumn
+}
+
+type uniqueIndexColumn struct {
+	name      string
+	collation string
+	prefix    int64
+}
+
+// readUniqueIndexes returns the unique indexes of each migrated table in
+// MySQL, including primary keys.
+func readUniqueIndexes(db DB) (map[string][]*uniqueIndex, error) {
+	condition, args := mysqlSchemaCondition(db, "s.table_schema")
+	query := `
+	SELECT s.table_schema,
+	       s.table_name,
+	       s.index_name,
+	       s.column_name,
+	       c.collation_name,
+	       s.sub_part
+	FROM   information_schema.statistics s
+	       JOIN information_schema.columns c
+	         ON c.table_schema = s.table_schema
+	            AND c.table_name = s.table_name
+	            AND c.column_name = s.column_name
+	WHERE  ` + condition + `
+	       AND s.non_unique = 0
+	ORDER  BY s.table_schema,
+	          s.table_name,
+	          s.index_name,
+	          s.seq_in_index`
+	rows, err := db.Querier().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := map[string][]*uniqueIndex{}
+	var index *uniqueIndex
+	var indexTable string
+	for rows.Next() {
+		var (
+			tableSchema, tableName, name, column string
+			collation                            sql.NullString
+			prefix                               sql.NullInt64
+		)
+
+		if err := rows.Scan(&tableSchema, &tableName, &name, &column, &collation, &prefix); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		table, ok := db.TableName(tableSchema, tableName)
+		if !ok {
+			continue
+		}
+
+		// index names are only unique within a table
+		if index == nil || index.name != name || indexTable != table {
+			index = &uniqueIndex{name: name}
+			indexTable = table
+			indexes[table] = append(indexes[table], index)
+		}
+
+		index.columns = append(index.columns, uniqueIndexColumn{
+			name:      column,
+			collation: collation.String,
+			prefix:    prefix.Int64,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return indexes, rows.Close()
+}
+
+// Accented Latin letters and the letters they are equal to in the accent
+// insensitive MySQL collations.
+const (
+	accentedLetters   = "ÀÁÂÃÄÅàáâãäåÇçÈÉÊËèéêëÌÍÎÏìíîïÑñÒÓÔÕÖòóôõöÙÚÛÜùúûüÝýÿ"
+	unaccentedLetters = "AAAAAAaaaaaaCcEEEEeeeeIIIIiiiiNnOOOOOoooooUUUUuuuuYyy"
+)
+
+// collatedValue returns a PostgreSQL expression for the value of column as
+// it is compared under collation in MySQL. Case and the accents of Latin
+// letters are ignored by case insensitive collations, other than those
+// that are explicitly accent sensitive, and trailing spaces by all but
+// the NO PAD collations of MySQL 8.
+func collatedValue(column string, collation string, prefix int64) string {
+	value := fmt.Sprintf("%s::text", column)
+	if collation == "" {
+		return value
+	}
+
+	if prefix > 0 {
+		value = fmt.Sprintf("left(%s, %d)", value, prefix)
+	}
+
+	if !strings.Contains(collation, "_0900_") {
+		value = fmt.Sprintf("rtrim(%s, ' ')", value)
+	}
+
+	caseInsensitive := strings.HasSuffix(collation, "_ci")
+	if caseInsensitive {
+		value = fmt.Sprintf("lower(%s)", value)
+	}
+
+	if (caseInsensitive && !strings.Contains(collation, "_as_")) || strings.Contains(collation, "_ai_") {
+		value = fmt.Sprintf("translate(%s, '%s', '%s')", value, accentedLetters, unaccentedLetters)
+	}
+
+	return value
+}
+
+// getUniqueCollisions returns the groups of rows of src that would be
+// duplicates under one of indexes, the unique indexes of the matching MySQL
+// table. Rows with a NULL in an indexed column never collide.
+func getUniqueCollisions(db DB, src *Table, indexes []*uniqueIndex) ([]UniqueCollision, error) {
+	var collisions []UniqueCollision
+
+indexes:
+	for _, index := range indexes {
+		values := make([]string, len(index.columns))
+		notNull := make([]string, len(index.columns))
+		for i, column := range index.columns {
+			if !src.HasColumn(column.name) {
+				continue indexes
+			}
+
+			values[i] = collatedValue(column.name, column.collation, column.prefix)
+			notNull[i] = fmt.Sprintf("%s IS NOT NULL", column.name)
+		}
+
+		// rows are grouped by each value, and described by a single string
+		// that quotes the values of composite indexes
+		value := values[0]
+		if len(values) > 1 {
+			value = fmt.Sprintf("ROW(%s)::text", strings.Join(values, ", "))
+		}
+		partition := strings.Join(values, ", ")
+		where := strings.Join(notNull, " AND ")
+
+		var indexCollisions []UniqueCollision
+		var err error
+		if src.HasKey() {
+			indexCollisions, err = collidingRowKeys(db, src, value, partition, where)
+		} else {
+			indexCollisions, err = collidingRowCounts(db, src, value, partition, where)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed checking unique index %s: %s", index.name, err)
+		}
+
+		for i := range indexCollisions {
+			indexCollisions[i].Index = index.name
+		}
+
+		collisions = append(collisions, indexCollisions...)
+	}
+
+	return collisions, nil
+}
+
+// collidingRowKeys returns the keys of the rows of table that share the
+// values in partition with another row, grouped by value.
+func collidingRowKeys(db DB, table *Table, value, partition, where string) ([]UniqueCollision, error) {
+	_, keyColumns, err := table.GetKeyColumns()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting key columns: %s", err)
+	}
+
+	keyNames := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		keyNames[i] = column.Name
+	}
+	keys := strings.Join(keyNames, ",")
+
+	stmt := fmt.Sprintf(`
+	SELECT v, %s
+	FROM   (SELECT %s AS v, %s, count(*) OVER (PARTITION BY %s) AS n
+	        FROM   %s
+	        WHERE  %s) x
+	WHERE  n > 1
+	ORDER  BY v, %s`, keys, value, keys, partition, table.Name, where, keys)
+
+	rows, err := db.Querier().Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var v string
+	keyValues := make([]interface{}, len(keyColumns))
+	scanArgs := make([]interface{}, len(keyColumns)+1)
+	scanArgs[0] = &v
+	for i := range keyValues {
+		scanArgs[i+1] = &keyValues[i]
+	}
+
+	var collisions []UniqueCollision
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %s", err)
+		}
+
+		if len(collisions) == 0 || collisions[len(collisions)-1].Value != v {
+			collisions = append(collisions, UniqueCollision{Value: v})
+		}
+
+		collision := &collisions[len(collisions)-1]
+		collision.RowIDs = append(collision.RowIDs, NewRowKey(keyValues))
+		collision.RowCount++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collisions, rows.Close()
+}
+
+// collidingRowCounts returns the number of rows of table sharing each of
+// the values in partition that more than one row has.
+func collidingRowCounts(db DB, table *Table, value, partition, where string) ([]UniqueCollision, error) {
+	stmt := fmt.Sprintf(`
+	SELECT %s, count(*)
+	FROM   %s
+	WHERE  %s
+	GROUP  BY %s
+	HAVING count(*) > 1
+	ORDER  BY 1`, value, table.Name, where, partition)
+
+	rows, err := db.Querier().Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	var collisions []UniqueCollision
+	for rows.Next() {
+		var collision UniqueCollision
+		if err := rows.Scan(&collision.Value, &collision.RowCount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %s", err)
+		}
+		collisions = append(collisions, collision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collisions, rows.Close()
+}
@@ -1,25 +1,54 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/pivotal-cf/pg2mysql"
 )
 
 type MigrateCommand struct {
-	Truncate bool `long:"truncate" description:"Truncate destination tables before migrating data"`
+	TableFilterOptions
+
+	Truncate   bool   `long:"truncate" description:"Truncate destination tables before migrating data"`
+	BatchSize  int    `long:"batch-size" default:"1000" description:"Maximum number of rows to insert per statement"`
+	BatchBytes int    `long:"batch-bytes" description:"Maximum size in bytes of each insert statement (defaults to the server's max_allowed_packet)"`
+	LoadData   bool   `long:"load-data" description:"Copy tables that are empty in MySQL using LOAD DATA LOCAL INFILE"`
+	Jobs       int    `short:"j" long:"jobs" default:"1" description:"Number of tables, or chunks of tables, to migrate concurrently"`
+	ChunkSize  int64  `long:"chunk-size" description:"Split tables with more rows than this into ranges of keys that can be migrated concurrently"`
+	Checkpoint string `long:"checkpoint" description:"Path to a file in which to record the progress of the migration"`
+	Resume     bool   `long:"resume" description:"Resume the migration recorded in the checkpoint file"`
+
+	Infinity         string `long:"infinity" description:"Store infinite dates and times as this value, e.g. '9999-12-31 23:59:59' or 'null'"`
+	NegativeInfinity string `long:"negative-infinity" description:"Store negatively infinite dates and times as this value, e.g. '1000-01-01 00:00:00' or 'null'"`
+
+	SequenceTable string `long:"sequence-table" description:"Copy sequences that are not owned by a column into this MySQL table"`
 }
 
 func (c *MigrateCommand) Execute([]string) error {
+	filter, err := c.tableFilter()
+	if err != nil {
+		return err
+	}
+
+	schemas, err := PG2MySQL.Config.SchemaMap()
+	if err != nil {
+		return fmt.Errorf("invalid schema mapping: %s", err)
+	}
+
 	mysql := pg2mysql.NewMySQLDB(
 		PG2MySQL.Config.MySQL.Database,
 		PG2MySQL.Config.MySQL.Username,
 		PG2MySQL.Config.MySQL.Password,
 		PG2MySQL.Config.MySQL.Host,
 		PG2MySQL.Config.MySQL.Port,
+		PG2MySQL.Config.MySQL.Charset,
+		PG2MySQL.Config.MySQL.Collation,
+		schemas,
+		filter,
 	)
 
-	err := mysql.Open()
+	err = mysql.Open()
 	if err != nil {
 		return fmt.Errorf("failed to open mysql connection: %s", err)
 	}
@@ -32,6 +61,8 @@ func (c *MigrateCommand) Execute([]string) error {
 		PG2MySQL.Config.PostgreSQL.Host,
 		PG2MySQL.Config.PostgreSQL.Port,
 		PG2MySQL.Config.PostgreSQL.SSLMode,
+		schemas,
+		filter,
 	)
 	err = pg.Open()
 	if err != nil {
@@ -40,7 +71,45 @@ func (c *MigrateCommand) Execute([]string) error {
 	defer pg.Close()
 
 	watcher := pg2mysql.NewStdoutPrinter()
-	err = pg2mysql.NewMigrator(pg, mysql, c.Truncate, watcher).Migrate()
+	if c.Jobs > 1 {
+		watcher = pg2mysql.NewTaggedStdoutPrinter()
+	}
+
+	opts := []pg2mysql.MigratorOption{
+		pg2mysql.WithBatchSize(c.BatchSize, c.BatchBytes),
+		pg2mysql.WithLoadData(c.LoadData),
+		pg2mysql.WithJobs(c.Jobs),
+		pg2mysql.WithChunkSize(c.ChunkSize),
+	}
+
+	infinity := pg2mysql.InfinityMapping{}
+	for name, value := range map[string]string{"infinity": c.Infinity, "-infinity": c.NegativeInfinity} {
+		if value == "" {
+			continue
+		}
+
+		v, err := pg2mysql.ParseInfinityValue(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", name, err)
+		}
+		infinity[name] = v
+	}
+	opts = append(opts, pg2mysql.WithInfinityMapping(infinity))
+
+	if c.SequenceTable != "" {
+		opts = append(opts, pg2mysql.WithSequenceTable(c.SequenceTable))
+	}
+
+	if c.Resume && c.Checkpoint == "" {
+		return errors.New("--resume requires --checkpoint")
+	}
+
+	if c.Checkpoint != "" {
+		store := pg2mysql.NewFileCheckpointStore(c.Checkpoint)
+		opts = append(opts, pg2mysql.WithCheckpoint(store, c.Resume))
+	}
+
+	err = pg2mysql.NewMigrator(pg, mysql, c.Truncate, watcher, opts...).Migrate()
 	if err != nil {
 		return fmt.Errorf("failed migrating: %s", err)
 	}
@@ -33,9 +33,11 @@ type PG2MySQLCommand struct {
 
 	ConfigFile ConfigFilePath `short:"c" long:"config" required:"true" description:"Path to config file"`
 
-	Validate ValidateCommand `command:"validate" description:"Validate that the data in PostgreSQL can be migrated to MySQL"`
-	Migrate  MigrateCommand  `command:"migrate" description:"Migrate data from PostgreSQL to MySQL"`
-	Verify   VerifyCommand   `command:"verify" description:"Verify migrated data matches"`
+	Validate   ValidateCommand   `command:"validate" description:"Validate that the data in PostgreSQL can be migrated to MySQL"`
+	Migrate    MigrateCommand    `command:"migrate" description:"Migrate data from PostgreSQL to MySQL"`
+	Verify     VerifyCommand     `command:"verify" description:"Verify migrated data matches"`
+	Schema     SchemaCommand     `command:"schema" description:"Generate a MySQL schema from the PostgreSQL schema"`
+	SchemaDiff SchemaDiffCommand `command:"schema-diff" description:"Report structural differences between the PostgreSQL and MySQL schemas"`
 }
 
 var PG2MySQL PG2MySQLCommand
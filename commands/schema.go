@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+type SchemaCommand struct {
+	TableFilterOptions
+
+	SizeFromData bool `long:"size-from-data" description:"Size text and binary columns from the longest values in PostgreSQL instead of making them as large as possible"`
+}
+
+func (c *SchemaCommand) Execute([]string) error {
+	filter, err := c.tableFilter()
+	if err != nil {
+		return err
+	}
+
+	schemas, err := PG2MySQL.Config.SchemaMap()
+	if err != nil {
+		return fmt.Errorf("invalid schema mapping: %s", err)
+	}
+
+	pg := pg2mysql.NewPostgreSQLDB(
+		PG2MySQL.Config.PostgreSQL.Database,
+		PG2MySQL.Config.PostgreSQL.Username,
+		PG2MySQL.Config.PostgreSQL.Password,
+		PG2MySQL.Config.PostgreSQL.Host,
+		PG2MySQL.Config.PostgreSQL.Port,
+		PG2MySQL.Config.PostgreSQL.SSLMode,
+		schemas,
+		filter,
+	)
+	err = pg.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open pg connection: %s", err)
+	}
+	defer pg.Close()
+
+	tables, err := pg2mysql.ReadTableDefinitions(pg)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %s", err)
+	}
+
+	if c.SizeFromData {
+		err = pg2mysql.SizeColumns(pg, tables)
+		if err != nil {
+			return fmt.Errorf("failed to size columns: %s", err)
+		}
+	}
+
+	err = pg2mysql.WriteMySQLSchema(os.Stdout, tables, schemas)
+	if err != nil {
+		return fmt.Errorf("failed to write schema: %s", err)
+	}
+
+	return nil
+}
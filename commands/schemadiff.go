@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+type SchemaDiffCommand struct {
+	TableFilterOptions
+}
+
+func (c *SchemaDiffCommand) Execute([]string) error {
+	filter, err := c.tableFilter()
+	if err != nil {
+		return err
+	}
+
+	schemas, err := PG2MySQL.Config.SchemaMap()
+	if err != nil {
+		return fmt.Errorf("invalid schema mapping: %s", err)
+	}
+
+	mysql := pg2mysql.NewMySQLDB(
+		PG2MySQL.Config.MySQL.Database,
+		PG2MySQL.Config.MySQL.Username,
+		PG2MySQL.Config.MySQL.Password,
+		PG2MySQL.Config.MySQL.Host,
+		PG2MySQL.Config.MySQL.Port,
+		PG2MySQL.Config.MySQL.Charset,
+		PG2MySQL.Config.MySQL.Collation,
+		schemas,
+		filter,
+	)
+
+	err = mysql.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open mysql connection: %s", err)
+	}
+	defer mysql.Close()
+
+	pg := pg2mysql.NewPostgreSQLDB(
+		PG2MySQL.Config.PostgreSQL.Database,
+		PG2MySQL.Config.PostgreSQL.Username,
+		PG2MySQL.Config.PostgreSQL.Password,
+		PG2MySQL.Config.PostgreSQL.Host,
+		PG2MySQL.Config.PostgreSQL.Port,
+		PG2MySQL.Config.PostgreSQL.SSLMode,
+		schemas,
+		filter,
+	)
+	err = pg.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open pg connection: %s", err)
+	}
+	defer pg.Close()
+
+	differences, err := pg2mysql.DiffSchemas(pg, mysql)
+	if err != nil {
+		return fmt.Errorf("failed to diff schemas: %s", err)
+	}
+
+	if len(differences) == 0 {
+		fmt.Println("no differences")
+		return nil
+	}
+
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+
+	fmt.Printf("found %d differences\n", len(differences))
+
+	return nil
+}
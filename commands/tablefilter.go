@@ -0,0 +1,14 @@
+package commands
+
+import "github.com/pivotal-cf/pg2mysql"
+
+// TableFilterOptions adds table patterns to the include and exclude lists
+// of the config.
+type TableFilterOptions struct {
+	Include []string `long:"include" description:"Only use tables whose names match this glob pattern (can be repeated)"`
+	Exclude []string `long:"exclude" description:"Skip tables whose names match this glob pattern (can be repeated)"`
+}
+
+func (o *TableFilterOptions) tableFilter() (pg2mysql.TableFilter, error) {
+	return PG2MySQL.Config.TableFilter(o.Include, o.Exclude)
+}
@@ -6,18 +6,34 @@ import (
 	"github.com/pivotal-cf/pg2mysql"
 )
 
-type ValidateCommand struct{}
+type ValidateCommand struct {
+	TableFilterOptions
+}
 
 func (c *ValidateCommand) Execute([]string) error {
+	filter, err := c.tableFilter()
+	if err != nil {
+		return err
+	}
+
+	schemas, err := PG2MySQL.Config.SchemaMap()
+	if err != nil {
+		return fmt.Errorf("invalid schema mapping: %s", err)
+	}
+
 	mysql := pg2mysql.NewMySQLDB(
 		PG2MySQL.Config.MySQL.Database,
 		PG2MySQL.Config.MySQL.Username,
 		PG2MySQL.Config.MySQL.Password,
 		PG2MySQL.Config.MySQL.Host,
 		PG2MySQL.Config.MySQL.Port,
+		PG2MySQL.Config.MySQL.Charset,
+		PG2MySQL.Config.MySQL.Collation,
+		schemas,
+		filter,
 	)
 
-	err := mysql.Open()
+	err = mysql.Open()
 	if err != nil {
 		return fmt.Errorf("failed to open mysql connection: %s", err)
 	}
@@ -30,6 +46,8 @@ func (c *ValidateCommand) Execute([]string) error {
 		PG2MySQL.Config.PostgreSQL.Host,
 		PG2MySQL.Config.PostgreSQL.Port,
 		PG2MySQL.Config.PostgreSQL.SSLMode,
+		schemas,
+		filter,
 	)
 	err = pg.Open()
 	if err != nil {
@@ -48,11 +66,27 @@ func (c *ValidateCommand) Execute([]string) error {
 			fmt.Printf("found %d incompatible rows in %s with IDs %v\n", result.IncompatibleRowCount, result.TableName, result.IncompatibleRowIDs)
 
 		case result.IncompatibleRowCount > 0:
-			fmt.Printf("found %d incompatible rows in %s (which has no 'id' column)\n", result.IncompatibleRowCount, result.TableName)
+			fmt.Printf("found %d incompatible rows in %s (which has no key)\n", result.IncompatibleRowCount, result.TableName)
 
-		default:
+		case len(result.UnsafeColumns) == 0 && len(result.InvalidColumns) == 0 && len(result.UniqueCollisions) == 0 && len(result.ForeignKeyViolations) == 0:
 			fmt.Printf("%s OK\n", result.TableName)
 		}
+
+		for _, column := range result.UnsafeColumns {
+			fmt.Printf("column %s.%s\n", result.TableName, column)
+		}
+
+		for _, column := range result.InvalidColumns {
+			fmt.Printf("column %s.%s\n", result.TableName, column)
+		}
+
+		for _, collision := range result.UniqueCollisions {
+			fmt.Printf("in %s, %s\n", result.TableName, collision)
+		}
+
+		for _, violation := range result.ForeignKeyViolations {
+			fmt.Printf("in %s, %s\n", result.TableName, violation)
+		}
 	}
 
 	return nil
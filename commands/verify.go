@@ -6,18 +6,34 @@ import (
 	"github.com/pivotal-cf/pg2mysql"
 )
 
-type VerifyCommand struct{}
+type VerifyCommand struct {
+	TableFilterOptions
+}
 
 func (c *VerifyCommand) Execute([]string) error {
+	filter, err := c.tableFilter()
+	if err != nil {
+		return err
+	}
+
+	schemas, err := PG2MySQL.Config.SchemaMap()
+	if err != nil {
+		return fmt.Errorf("invalid schema mapping: %s", err)
+	}
+
 	mysql := pg2mysql.NewMySQLDB(
 		PG2MySQL.Config.MySQL.Database,
 		PG2MySQL.Config.MySQL.Username,
 		PG2MySQL.Config.MySQL.Password,
 		PG2MySQL.Config.MySQL.Host,
 		PG2MySQL.Config.MySQL.Port,
+		PG2MySQL.Config.MySQL.Charset,
+		PG2MySQL.Config.MySQL.Collation,
+		schemas,
+		filter,
 	)
 
-	err := mysql.Open()
+	err = mysql.Open()
 	if err != nil {
 		return fmt.Errorf("failed to open mysql connection: %s", err)
 	}
@@ -30,6 +46,8 @@ func (c *VerifyCommand) Execute([]string) error {
 		PG2MySQL.Config.PostgreSQL.Host,
 		PG2MySQL.Config.PostgreSQL.Port,
 		PG2MySQL.Config.PostgreSQL.SSLMode,
+		schemas,
+		filter,
 	)
 	err = pg.Open()
 	if err != nil {
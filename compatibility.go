@@ -0,0 +1,246 @@
+package pg2mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Compatibility classifies how well the values of a source column can be
+// stored in a destination column.
+type Compatibility int
+
+const (
+	// Safe means every value can be stored exactly.
+	Safe Compatibility = iota
+
+	// Lossy means every value can be stored, but some lose precision, such
+	// as fractional seconds or digits after the decimal point.
+	Lossy
+
+	// Incompatible means some values cannot be stored at all, because they
+	// are out of range or of a different kind.
+	Incompatible
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Safe:
+		return "safe"
+	case Lossy:
+		return "lossy"
+	default:
+		return "incompatible"
+	}
+}
+
+// integerBits holds the width of each integer type. PostgreSQL integers are
+// always signed; MySQL integers may be unsigned.
+var integerBits = map[string]int64{
+	"boolean":   1,
+	"tinyint":   8,
+	"smallint":  16,
+	"mediumint": 24,
+	"integer":   32,
+	"int":       32,
+	"bigint":    64,
+}
+
+// floatBits holds the width of the significand of each floating point type.
+var floatBits = map[string]int64{
+	"real":             24,
+	"float":            24,
+	"double precision": 53,
+	"double":           53,
+}
+
+// integerDigits holds the number of decimal digits needed for the largest
+// value of each signed integer type.
+var integerDigits = map[string]int64{
+	"boolean":   1,
+	"tinyint":   3,
+	"smallint":  5,
+	"mediumint": 7,
+	"integer":   10,
+	"int":       10,
+	"bigint":    19,
+}
+
+// CompatibilityWith classifies storing the values of c, a source column,
+// in dst. Character lengths are not considered here, since they can only
+// be judged against the data. Types that are not known are assumed safe.
+func (c *Column) CompatibilityWith(dst *Column) Compatibility {
+	srcFamily, dstFamily := typeFamilies[c.Type], typeFamilies[dst.Type]
+	if srcFamily == "" || dstFamily == "" {
+		return Safe
+	}
+
+	// MySQL decimals and floats may also be unsigned
+	if (dstFamily == "decimal" || dstFamily == "float") && dst.Unsigned && !c.Unsigned {
+		return Incompatible
+	}
+
+	if srcFamily != dstFamily {
+		return crossFamilyCompatibility(c, dst, srcFamily, dstFamily)
+	}
+
+	switch srcFamily {
+	case "integer":
+		return integerCompatibility(c, dst)
+	case "decimal":
+		return decimalCompatibility(c, dst)
+	case "float":
+		if floatBits[c.Type] > floatBits[dst.Type] {
+			return Lossy
+		}
+	case "datetime", "time":
+		if c.DatetimePrecision > dst.DatetimePrecision {
+			return Lossy
+		}
+	}
+
+	return Safe
+}
+
+func integerCompatibility(src, dst *Column) Compatibility {
+	srcBits, dstBits := integerBits[src.Type], integerBits[dst.Type]
+	if srcBits == 0 || dstBits == 0 {
+		return Safe
+	}
+
+	// a signed type needs one more bit than an unsigned type of the same range
+	if !src.Unsigned && src.Type != "boolean" {
+		if dst.Unsigned {
+			return Incompatible
+		}
+	} else if !dst.Unsigned {
+		dstBits--
+	}
+
+	if srcBits > dstBits {
+		return Incompatible
+	}
+
+	return Safe
+}
+
+func decimalCompatibility(src, dst *Column) Compatibility {
+	precision, scale := src.NumericPrecision, src.NumericScale
+	if src.Type == "money" {
+		precision, scale = 19, 2
+	}
+
+	// an unconstrained numeric may hold any value
+	if precision == 0 {
+		return Lossy
+	}
+
+	if precision-scale > dst.NumericPrecision-dst.NumericScale {
+		return Incompatible
+	}
+
+	if scale > dst.NumericScale {
+		return Lossy
+	}
+
+	return Safe
+}
+
+func crossFamilyCompatibility(src, dst *Column, srcFamily, dstFamily string) Compatibility {
+	switch {
+	case dstFamily == "string":
+		// anything can be stored as text, but binary data may not be valid
+		// in the character set of the column
+		if srcFamily == "binary" {
+			return Incompatible
+		}
+		return Safe
+
+	case srcFamily == "integer" && dstFamily == "decimal":
+		if integerDigits[src.Type] > dst.NumericPrecision-dst.NumericScale {
+			return Incompatible
+		}
+		return Safe
+
+	case srcFamily == "integer" && dstFamily == "float":
+		if integerBits[src.Type] > floatBits[dst.Type] {
+			return Lossy
+		}
+		return Safe
+
+	case srcFamily == "decimal" && dstFamily == "float",
+		srcFamily == "float" && dstFamily == "decimal",
+		srcFamily == "datetime" && dstFamily == "date":
+		return Lossy
+
+	case srcFamily == "date" && dstFamily == "datetime":
+		return Safe
+	}
+
+	return Incompatible
+}
+
+// describeType returns the type of c along with its length, precision or
+// signedness, e.g. "numeric(20,4)".
+func describeType(c *Column) string {
+	switch typeFamilies[c.Type] {
+	case "integer":
+		if c.Unsigned {
+			return c.Type + " unsigned"
+		}
+	case "decimal":
+		if c.NumericPrecision > 0 {
+			return fmt.Sprintf("%s(%d,%d)", c.Type, c.NumericPrecision, c.NumericScale)
+		}
+	case "datetime", "time":
+		if c.DatetimePrecision > 0 {
+			// PostgreSQL puts the precision before the time zone
+			name, zone := c.Type, ""
+			if i := strings.Index(c.Type, " with"); i >= 0 {
+				name, zone = c.Type[:i], c.Type[i:]
+			}
+			return fmt.Sprintf("%s(%d)%s", name, c.DatetimePrecision, zone)
+		}
+	case "string", "binary":
+		if c.MaxChars > 0 {
+			return fmt.Sprintf("%s(%d)", c.Type, c.MaxChars)
+		}
+	}
+
+	return c.Type
+}
+
+// ColumnConversion describes a column whose values are not safe to store
+// in the destination column.
+type ColumnConversion struct {
+	Column          string
+	SourceType      string
+	DestinationType string
+	Compatibility   Compatibility
+}
+
+func (c ColumnConversion) String() string {
+	return fmt.Sprintf("%s: %s to %s is %s", c.Column, c.SourceType, c.DestinationType, c.Compatibility)
+}
+
+// GetUnsafeColumns returns the columns of src whose types are not safe to
+// store in the matching columns of dst.
+func GetUnsafeColumns(src, dst *Table) []ColumnConversion {
+	var conversions []ColumnConversion
+	for _, srcColumn := range src.Columns {
+		_, dstColumn, err := dst.GetColumn(srcColumn.Name)
+		if err != nil {
+			continue
+		}
+
+		if compatibility := srcColumn.CompatibilityWith(dstColumn); compatibility != Safe {
+			conversions = append(conversions, ColumnConversion{
+				Column:          srcColumn.Name,
+				SourceType:      describeType(srcColumn),
+				DestinationType: describeType(dstColumn),
+				Compatibility:   compatibility,
+			})
+		}
+	}
+
+	return conversions
+}
@@ -0,0 +1,42 @@
+package pg2mysql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("Column", func() {
+	Describe("CompatibilityWith", func() {
+		compatibility := func(src, dst pg2mysql.Column) pg2mysql.Compatibility {
+			return src.CompatibilityWith(&dst)
+		}
+
+		It("checks the range of integers", func() {
+			Expect(compatibility(pg2mysql.Column{Type: "integer"}, pg2mysql.Column{Type: "bigint"})).To(Equal(pg2mysql.Safe))
+			Expect(compatibility(pg2mysql.Column{Type: "bigint"}, pg2mysql.Column{Type: "int"})).To(Equal(pg2mysql.Incompatible))
+			Expect(compatibility(pg2mysql.Column{Type: "integer"}, pg2mysql.Column{Type: "int", Unsigned: true})).To(Equal(pg2mysql.Incompatible))
+			Expect(compatibility(pg2mysql.Column{Type: "boolean"}, pg2mysql.Column{Type: "tinyint"})).To(Equal(pg2mysql.Safe))
+		})
+
+		It("checks the precision and scale of decimals", func() {
+			Expect(compatibility(pg2mysql.Column{Type: "numeric", NumericPrecision: 10, NumericScale: 2}, pg2mysql.Column{Type: "decimal", NumericPrecision: 12, NumericScale: 4})).To(Equal(pg2mysql.Safe))
+			Expect(compatibility(pg2mysql.Column{Type: "numeric", NumericPrecision: 10, NumericScale: 4}, pg2mysql.Column{Type: "decimal", NumericPrecision: 10, NumericScale: 2})).To(Equal(pg2mysql.Lossy))
+			Expect(compatibility(pg2mysql.Column{Type: "numeric", NumericPrecision: 20, NumericScale: 4}, pg2mysql.Column{Type: "decimal", NumericPrecision: 10, NumericScale: 2})).To(Equal(pg2mysql.Incompatible))
+			Expect(compatibility(pg2mysql.Column{Type: "numeric"}, pg2mysql.Column{Type: "decimal", NumericPrecision: 65, NumericScale: 30})).To(Equal(pg2mysql.Lossy))
+		})
+
+		It("checks the precision of times", func() {
+			Expect(compatibility(pg2mysql.Column{Type: "timestamp without time zone", DatetimePrecision: 6}, pg2mysql.Column{Type: "datetime", DatetimePrecision: 6})).To(Equal(pg2mysql.Safe))
+			Expect(compatibility(pg2mysql.Column{Type: "timestamp without time zone", DatetimePrecision: 6}, pg2mysql.Column{Type: "datetime"})).To(Equal(pg2mysql.Lossy))
+			Expect(compatibility(pg2mysql.Column{Type: "timestamp with time zone"}, pg2mysql.Column{Type: "date"})).To(Equal(pg2mysql.Lossy))
+		})
+
+		It("classifies conversions between kinds of values", func() {
+			Expect(compatibility(pg2mysql.Column{Type: "integer"}, pg2mysql.Column{Type: "varchar"})).To(Equal(pg2mysql.Safe))
+			Expect(compatibility(pg2mysql.Column{Type: "text"}, pg2mysql.Column{Type: "int"})).To(Equal(pg2mysql.Incompatible))
+			Expect(compatibility(pg2mysql.Column{Type: "bigint"}, pg2mysql.Column{Type: "double"})).To(Equal(pg2mysql.Lossy))
+			Expect(compatibility(pg2mysql.Column{Type: "USER-DEFINED"}, pg2mysql.Column{Type: "varchar"})).To(Equal(pg2mysql.Safe))
+		})
+	})
+})
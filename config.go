@@ -7,6 +7,11 @@ type Config struct {
 		Password string `yaml:"password"`
 		Host     string `yaml:"host"`
 		Port     int    `yaml:"port"`
+
+		// Charset and Collation set the character set of the connection.
+		// They default to utf8mb4 and its default collation.
+		Charset   string `yaml:"charset"`
+		Collation string `yaml:"collation"`
 	} `yaml:"mysql"`
 
 	PostgreSQL struct {
@@ -16,5 +21,53 @@ type Config struct {
 		Host     string `yaml:"host"`
 		Port     int    `yaml:"port"`
 		SSLMode  string `yaml:"ssl_mode"`
+
+		// Schemas lists the schemas to migrate, public if it is empty, and
+		// SchemaMapping where in MySQL the tables of each are stored.
+		Schemas       []string                `yaml:"schemas"`
+		SchemaMapping map[string]SchemaTarget `yaml:"schema_mapping"`
+
+		// Include and Exclude select the tables to migrate with glob
+		// patterns. Exclude defaults to schema_migrations if it is not set.
+		Include []string `yaml:"include"`
+		Exclude []string `yaml:"exclude"`
 	} `yaml:"postgresql"`
 }
+
+// defaultExclude holds the tables excluded when no exclude list is given.
+var defaultExclude = []string{"schema_migrations"}
+
+// TableFilter returns the filter selecting the tables to migrate, from the
+// include and exclude patterns of the config and those given in addition.
+func (c *Config) TableFilter(include, exclude []string) (TableFilter, error) {
+	configExclude := c.PostgreSQL.Exclude
+	if configExclude == nil {
+		configExclude = defaultExclude
+	}
+
+	return ParseTableFilter(
+		append(append([]string{}, c.PostgreSQL.Include...), include...),
+		append(append([]string{}, configExclude...), exclude...),
+	)
+}
+
+// SchemaMap returns the PostgreSQL schemas to migrate, with where in MySQL
+// the tables of each are stored. It returns an error if the tables of two
+// schemas would be stored so that they cannot be told apart.
+func (c *Config) SchemaMap() (SchemaMap, error) {
+	schemas := c.PostgreSQL.Schemas
+	if len(schemas) == 0 {
+		schemas = []string{defaultSchema}
+	}
+
+	m := SchemaMap{}
+	for _, schema := range schemas {
+		m[schema] = c.PostgreSQL.SchemaMapping[schema]
+	}
+
+	if err := m.Validate(c.MySQL.Database); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -1,9 +1,9 @@
 package pg2mysql
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 )
@@ -12,10 +12,39 @@ type DB interface {
 	Open() error
 	Close() error
 	GetSchemaRows() (*sql.Rows, error)
+	GetKeyRows() (*sql.Rows, error)
 	DisableConstraints() error
 	EnableConstraints() error
+	BeginSnapshot() error
+	EndSnapshot() error
 	ColumnNameForSelect(columnName string) string
+
+	// Schemas returns the PostgreSQL schemas, or the MySQL databases, that
+	// hold the migrated tables.
+	Schemas() []string
+
+	// TableName returns the qualified name of the PostgreSQL table that a
+	// table found in the catalogs under schema, a PostgreSQL schema or a
+	// MySQL database, stands for, or false if the table is not migrated.
+	TableName(schema, table string) (string, bool)
+
+	// TableNameForQuery returns the identifier to use in queries for the
+	// table with the given qualified name.
+	TableNameForQuery(tableName string) string
+
+	// Querier returns what queries are run through, which is the
+	// transaction of the snapshot while one is open.
+	Querier() Querier
+
 	DB() *sql.DB
+	Clone() DB
+}
+
+// Querier runs statements on a connection pool or within a transaction.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 type Schema struct {
@@ -31,8 +60,15 @@ func (s *Schema) GetTable(name string) (*Table, error) {
 }
 
 type Table struct {
+	// Name is the name of the table in PostgreSQL, qualified by its schema
+	// unless that is the public schema. Schema is the owning schema.
 	Name    string
+	Schema  string
 	Columns []*Column
+
+	// PrimaryKey holds the names of the columns of the table's primary key,
+	// or of its first unique index on NOT NULL columns if it has none.
+	PrimaryKey []string
 }
 
 func (t *Table) HasColumn(name string) bool {
@@ -50,22 +86,157 @@ func (t *Table) GetColumn(name string) (int, *Column, error) {
 	return -1, nil, fmt.Errorf("column '%s' not found", name)
 }
 
+// HasKey reports whether the table has a key.
+func (t *Table) HasKey() bool {
+	return len(t.PrimaryKey) > 0
+}
+
+// GetKeyColumns returns the columns making up the table's key, along with
+// their indexes, in key order.
+func (t *Table) GetKeyColumns() ([]int, []*Column, error) {
+	if !t.HasKey() {
+		return nil, nil, fmt.Errorf("table '%s' has no key", t.Name)
+	}
+
+	indexes := make([]int, len(t.PrimaryKey))
+	columns := make([]*Column, len(t.PrimaryKey))
+	for i, name := range t.PrimaryKey {
+		index, column, err := t.GetColumn(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		indexes[i] = index
+		columns[i] = column
+	}
+
+	return indexes, columns, nil
+}
+
+// RowKey holds the values of the key columns of a row.
+type RowKey []string
+
+// NewRowKey returns the key of a row from the values of its key columns.
+func NewRowKey(values []interface{}) RowKey {
+	key := make(RowKey, len(values))
+	for i, v := range values {
+		if iface, ok := v.(*interface{}); ok {
+			v = *iface
+		}
+
+		if bs, ok := v.([]byte); ok {
+			v = string(bs)
+		}
+
+		key[i] = fmt.Sprintf("%v", v)
+	}
+
+	return key
+}
+
+// String returns the key value of a single-column key, and the key values
+// in parentheses for a composite key.
+func (k RowKey) String() string {
+	if len(k) == 1 {
+		return k[0]
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(k, ", "))
+}
+
 type Column struct {
 	Name     string
 	Type     string
 	MaxChars int64
+	Nullable bool
+	Default  *string
+
+	NumericPrecision  int64
+	NumericScale      int64
+	DatetimePrecision int64
+	Unsigned          bool
+
+	// Charset is the character set of a MySQL character column, and
+	// MaxBytes the maximum length in bytes of a character or binary column.
+	Charset  string
+	MaxBytes int64
 }
 
+// Compatible reports whether every value of other, the matching source
+// column, is known to fit within the length of c.
 func (c *Column) Compatible(other *Column) bool {
-	if c.MaxChars == 0 && other.MaxChars == 0 {
+	limit, inBytes := c.lengthLimit()
+	if limit == 0 {
 		return true
 	}
 
-	if c.MaxChars > 0 && other.MaxChars > 0 {
-		return c.MaxChars < other.MaxChars
+	if inBytes {
+		return other.MaxBytes > 0 && other.MaxBytes <= limit
 	}
 
-	return false
+	return other.MaxChars > 0 && other.MaxChars <= limit
+}
+
+// byteLimitedTypes are the MySQL types whose length is limited in bytes
+// rather than characters.
+var byteLimitedTypes = map[string]bool{
+	"tinytext":   true,
+	"text":       true,
+	"mediumtext": true,
+	"longtext":   true,
+	"binary":     true,
+	"varbinary":  true,
+	"tinyblob":   true,
+	"blob":       true,
+	"mediumblob": true,
+	"longblob":   true,
+}
+
+// singleByteCharsets are the MySQL character sets that encode every
+// character in a single byte.
+var singleByteCharsets = map[string]bool{
+	"ascii":  true,
+	"latin1": true,
+	"latin2": true,
+	"latin5": true,
+	"latin7": true,
+	"cp1250": true,
+	"cp1251": true,
+	"cp1256": true,
+	"cp1257": true,
+	"greek":  true,
+	"hebrew": true,
+}
+
+// lengthLimit returns the maximum length of the values of c, a MySQL
+// column, and whether it is measured in bytes. A zero limit means that c
+// is not limited in length.
+func (c *Column) lengthLimit() (int64, bool) {
+	if !byteLimitedTypes[c.Type] {
+		return c.MaxChars, false
+	}
+
+	// in a single-byte character set characters and bytes are the same
+	if singleByteCharsets[c.Charset] {
+		return c.MaxBytes, false
+	}
+
+	return c.MaxBytes, true
+}
+
+// lengthCondition returns a PostgreSQL condition matching the values of src
+// that are too long for dst.
+func lengthCondition(src, dst *Column) string {
+	limit, inBytes := dst.lengthLimit()
+
+	switch {
+	case src.Type == "bytea":
+		return fmt.Sprintf("octet_length(%s) > %d", src.Name, limit)
+	case inBytes:
+		return fmt.Sprintf("octet_length(%s::text) > %d", src.Name, limit)
+	default:
+		return fmt.Sprintf("char_length(%s::text) > %d", src.Name, limit)
+	}
 }
 
 func (c *Column) Incompatible(other *Column) bool {
@@ -81,21 +252,63 @@ func BuildSchema(db DB) (*Schema, error) {
 	data := map[string][]*Column{}
 	for rows.Next() {
 		var (
-			table    sql.NullString
-			column   sql.NullString
-			datatype sql.NullString
-			maxChars sql.NullInt64
+			tableSchema   sql.NullString
+			table         sql.NullString
+			column        sql.NullString
+			datatype      sql.NullString
+			maxChars      sql.NullInt64
+			nullable      bool
+			columnDefault sql.NullString
+			precision     sql.NullInt64
+			scale         sql.NullInt64
+			datetimePrec  sql.NullInt64
+			unsigned      bool
+			charset       sql.NullString
+			maxBytes      sql.NullInt64
 		)
 
-		if err := rows.Scan(&table, &column, &datatype, &maxChars); err != nil {
+		err := rows.Scan(
+			&tableSchema,
+			&table,
+			&column,
+			&datatype,
+			&maxChars,
+			&nullable,
+			&columnDefault,
+			&precision,
+			&scale,
+			&datetimePrec,
+			&unsigned,
+			&charset,
+			&maxBytes,
+		)
+		if err != nil {
 			return nil, err
 		}
 
-		data[table.String] = append(data[table.String], &Column{
+		c := &Column{
 			Name:     column.String,
 			Type:     datatype.String,
 			MaxChars: maxChars.Int64,
-		})
+			Nullable: nullable,
+
+			NumericPrecision:  precision.Int64,
+			NumericScale:      scale.Int64,
+			DatetimePrecision: datetimePrec.Int64,
+			Unsigned:          unsigned,
+			Charset:           charset.String,
+			MaxBytes:          maxBytes.Int64,
+		}
+		if columnDefault.Valid {
+			c.Default = &columnDefault.String
+		}
+
+		name, ok := db.TableName(tableSchema.String, table.String)
+		if !ok {
+			continue
+		}
+
+		data[name] = append(data[name], c)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -106,20 +319,69 @@ func BuildSchema(db DB) (*Schema, error) {
 		return nil, fmt.Errorf("failed closing rows: %s", err)
 	}
 
+	keys, err := buildKeys(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find keys: %s", err)
+	}
+
 	schema := &Schema{
 		Tables: map[string]*Table{},
 	}
 
 	for k, v := range data {
+		tableSchema, _ := splitTableName(k)
 		schema.Tables[k] = &Table{
-			Name:    k,
-			Columns: v,
+			Name:       k,
+			Schema:     tableSchema,
+			Columns:    v,
+			PrimaryKey: keys[k],
 		}
 	}
 
 	return schema, nil
 }
 
+// buildKeys returns the key columns of each table. GetKeyRows lists the
+// columns of every candidate key in order, preferred key first, so only
+// the first index seen for each table is used.
+func buildKeys(db DB) (map[string][]string, error) {
+	rows, err := db.GetKeyRows()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string][]string{}
+	indexes := map[string]string{}
+	for rows.Next() {
+		var tableSchema, tableName, index, column string
+		if err := rows.Scan(&tableSchema, &tableName, &index, &column); err != nil {
+			return nil, err
+		}
+
+		table, ok := db.TableName(tableSchema, tableName)
+		if !ok {
+			continue
+		}
+
+		if keyIndex, ok := indexes[table]; ok && keyIndex != index {
+			continue
+		}
+
+		indexes[table] = index
+		keys[table] = append(keys[table], column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate through key rows: %s", err)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed closing rows: %s", err)
+	}
+
+	return keys, nil
+}
+
 func GetIncompatibleColumns(src, dst *Table) ([]*Column, error) {
 	var incompatibleColumns []*Column
 	for _, dstColumn := range dst.Columns {
@@ -136,34 +398,77 @@ func GetIncompatibleColumns(src, dst *Table) ([]*Column, error) {
 	return incompatibleColumns, nil
 }
 
-func GetIncompatibleRowIDs(db DB, src, dst *Table) ([]int, error) {
-	columns, err := GetIncompatibleColumns(src, dst)
+func GetIncompatibleRowIDs(db DB, src, dst *Table) ([]RowKey, error) {
+	limits, err := lengthConditions(src, dst)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting incompatible columns: %s", err)
 	}
 
-	if columns == nil {
+	if limits == nil {
 		return nil, nil
 	}
 
-	limits := make([]string, len(columns))
-	for i, column := range columns {
-		limits[i] = fmt.Sprintf("LENGTH(%s) > %d", column.Name, column.MaxChars)
+	rowIDs, err := selectRowKeys(db, src, strings.Join(limits, " OR "))
+	if err != nil {
+		return nil, fmt.Errorf("failed getting incompatible row ids: %s", err)
+	}
+
+	return rowIDs, nil
+}
+
+// lengthConditions returns a condition for each column of dst whose length
+// the values of src might exceed.
+func lengthConditions(src, dst *Table) ([]string, error) {
+	columns, err := GetIncompatibleColumns(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits []string
+	for _, dstColumn := range columns {
+		_, srcColumn, err := src.GetColumn(dstColumn.Name)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, lengthCondition(srcColumn, dstColumn))
 	}
 
-	stmt := fmt.Sprintf("SELECT id FROM %s WHERE %s", src.Name, strings.Join(limits, " OR "))
-	rows, err := db.DB().Query(stmt)
+	return limits, nil
+}
+
+// selectRowKeys returns the keys of the rows of table that match where, in
+// key order.
+func selectRowKeys(db DB, table *Table, where string) ([]RowKey, error) {
+	_, keyColumns, err := table.GetKeyColumns()
 	if err != nil {
-		return nil, fmt.Errorf("failed getting incompatible row ids: %s", err)
+		return nil, fmt.Errorf("failed getting key columns: %s", err)
+	}
+
+	keyNames := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		keyNames[i] = column.Name
 	}
 
-	var rowIDs []int
+	keys := strings.Join(keyNames, ",")
+	stmt := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s", keys, db.TableNameForQuery(table.Name), where, keys)
+	rows, err := db.Querier().Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keyColumns))
+	scanArgs := make([]interface{}, len(keyColumns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	var rowIDs []RowKey
 	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
+		if err := rows.Scan(scanArgs...); err != nil {
+			rows.Close()
 			return nil, fmt.Errorf("failed to scan row: %s", err)
 		}
-		rowIDs = append(rowIDs, id)
+		rowIDs = append(rowIDs, NewRowKey(values))
 	}
 
 	if err := rows.Err(); err != nil {
@@ -178,24 +483,19 @@ func GetIncompatibleRowIDs(db DB, src, dst *Table) ([]int, error) {
 }
 
 func GetIncompatibleRowCount(db DB, src, dst *Table) (int64, error) {
-	columns, err := GetIncompatibleColumns(src, dst)
+	limits, err := lengthConditions(src, dst)
 	if err != nil {
 		return 0, fmt.Errorf("failed getting incompatible columns: %s", err)
 	}
 
-	if columns == nil {
+	if limits == nil {
 		return 0, nil
 	}
 
-	limits := make([]string, len(columns))
-	for i, column := range columns {
-		limits[i] = fmt.Sprintf("length(%s) > %d", column.Name, column.MaxChars)
-	}
-
-	stmt := fmt.Sprintf("SELECT count(1) FROM %s WHERE %s", src.Name, strings.Join(limits, " OR "))
+	stmt := fmt.Sprintf("SELECT count(1) FROM %s WHERE %s", db.TableNameForQuery(src.Name), strings.Join(limits, " OR "))
 
 	var count int64
-	err = db.DB().QueryRow(stmt).Scan(&count)
+	err = db.Querier().QueryRow(stmt).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -203,58 +503,74 @@ func GetIncompatibleRowCount(db DB, src, dst *Table) (int64, error) {
 	return count, nil
 }
 
+// EachMissingRow calls f with each row of table in src that is missing from
+// dst. Rows are compared by hashing every column on both sides, so a row
+// that appears n times in src and m times in dst is reported n-m times.
+// Times in src are rounded to the precision of their column in dst first,
+// as MySQL does when it stores them.
+//
+// The number of rows in dst with each distinct hash is held in memory, so
+// memory use is O(rows) in dst. The migrator and the verifier only use it
+// for tables without a key that can be walked in order, and compare the
+// keys of other tables instead.
 func EachMissingRow(src, dst DB, table *Table, f func([]interface{})) error {
+	dstSchema, err := BuildSchema(dst)
+	if err != nil {
+		return fmt.Errorf("failed to build destination schema: %s", err)
+	}
+
+	return eachMissingRow(src, dst, table, dstSchema.Tables[table.Name], nil, f)
+}
+
+// eachMissingRow is EachMissingRow given the matching table in dst, which
+// may be nil, with the infinite dates and times of src replaced as given by
+// infinity before the rows are compared.
+func eachMissingRow(src, dst DB, table, dstTable *Table, infinity InfinityMapping, f func([]interface{})) error {
+	dstCounts, err := countRows(dst, table)
+	if err != nil {
+		return fmt.Errorf("failed to count rows in dst: %s", err)
+	}
+
+	precisions := timePrecisions(table, dstTable)
+
 	srcColumnNamesForSelect := make([]string, len(table.Columns))
 	values := make([]interface{}, len(table.Columns))
 	scanArgs := make([]interface{}, len(table.Columns))
-	colVals := make([]string, len(table.Columns))
 	for i := range table.Columns {
 		srcColumnNamesForSelect[i] = src.ColumnNameForSelect(table.Columns[i].Name)
 		scanArgs[i] = &values[i]
-		colVals[i] = fmt.Sprintf("%s <=> ?", dst.ColumnNameForSelect(table.Columns[i].Name))
 	}
 
 	// select all rows in src
-	stmt := fmt.Sprintf("SELECT %s FROM %s", strings.Join(srcColumnNamesForSelect, ","), table.Name)
-	rows, err := src.DB().Query(stmt)
+	stmt := fmt.Sprintf("SELECT %s FROM %s", strings.Join(srcColumnNamesForSelect, ","), src.TableNameForQuery(table.Name))
+	rows, err := src.Querier().Query(stmt)
 	if err != nil {
 		return fmt.Errorf("failed to select rows: %s", err)
 	}
 
-	stmt = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s)`, table.Name, strings.Join(colVals, " AND "))
-	preparedStmt, err := dst.DB().Prepare(stmt)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %s", err)
-	}
-
-	var exists bool
+	h := sha256.New()
 	for rows.Next() {
 		if err = rows.Scan(scanArgs...); err != nil {
 			return fmt.Errorf("failed to scan row: %s", err)
 		}
 
-		for i := range scanArgs {
-			arg := scanArgs[i]
-			iface, ok := arg.(*interface{})
-			if !ok {
-				log.Fatalf("received unexpected type as scanArg: %T (should be *interface{})", arg)
-			}
+		infinity.apply(table, values)
 
-			// replace the precise PostgreSQL time with a less precise MySQL-compatible time
-			if t1, ok := (*iface).(time.Time); ok {
-				var timeArg interface{} = t1.Truncate(time.Second)
-				scanArgs[i] = &timeArg
+		// round the PostgreSQL times as MySQL does when it stores them
+		for i := range values {
+			if t, ok := values[i].(time.Time); ok {
+				values[i] = t.Round(precisions[i])
 			}
 		}
 
-		// determine if the row exists in dst
-		if err = preparedStmt.QueryRow(scanArgs...).Scan(&exists); err != nil {
-			return fmt.Errorf("failed to check if row exists: %s", err)
+		// each row in dst accounts for one identical row in src
+		sum := hashRow(h, values)
+		if dstCounts[sum] > 0 {
+			dstCounts[sum]--
+			continue
 		}
 
-		if !exists {
-			f(scanArgs)
-		}
+		f(scanArgs)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -267,3 +583,27 @@ func EachMissingRow(src, dst DB, table *Table, f func([]interface{})) error {
 
 	return nil
 }
+
+// timePrecisions returns the precision to which MySQL rounds the times
+// stored in each column of table: that of the column of dstTable with the
+// same name, or whole seconds if there is none.
+func timePrecisions(table, dstTable *Table) []time.Duration {
+	precisions := make([]time.Duration, len(table.Columns))
+	for i, column := range table.Columns {
+		precisions[i] = time.Second
+		if dstTable == nil {
+			continue
+		}
+
+		_, dstColumn, err := dstTable.GetColumn(column.Name)
+		if err != nil {
+			continue
+		}
+
+		for digits := int64(0); digits < dstColumn.DatetimePrecision && digits < 9; digits++ {
+			precisions[i] /= 10
+		}
+	}
+
+	return precisions
+}
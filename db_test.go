@@ -0,0 +1,145 @@
+package pg2mysql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("BuildSchema", func() {
+	var (
+		mysql pg2mysql.DB
+		pg    pg2mysql.DB
+	)
+
+	BeforeEach(func() {
+		mysql = pg2mysql.NewMySQLDB(
+			mysqlRunner.DBName,
+			"root",
+			"",
+			"127.0.0.1",
+			3306,
+			"",
+			"",
+			nil,
+			pg2mysql.TableFilter{},
+		)
+		err := mysql.Open()
+		Expect(err).NotTo(HaveOccurred())
+
+		pg = pg2mysql.NewPostgreSQLDB(
+			pgRunner.DBName,
+			"",
+			"",
+			"127.0.0.1",
+			5432,
+			"disable",
+			nil,
+			pg2mysql.TableFilter{},
+		)
+		err = pg.Open()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := mysql.Close()
+		Expect(err).NotTo(HaveOccurred())
+		err = pg.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("when a table has a primary key", func() {
+		BeforeEach(func() {
+			_, err := pgRunner.DB().Exec("CREATE TABLE table_with_primary_key (code text PRIMARY KEY, id integer)")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_primary_key (code varchar(255) PRIMARY KEY, id int)")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, err := pgRunner.DB().Exec("DROP TABLE table_with_primary_key")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_primary_key")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reads the primary key from both databases", func() {
+			for _, db := range []pg2mysql.DB{pg, mysql} {
+				schema, err := pg2mysql.BuildSchema(db)
+				Expect(err).NotTo(HaveOccurred())
+
+				table := schema.Tables["table_with_primary_key"]
+				Expect(table.PrimaryKey).To(Equal([]string{"code"}))
+
+				_, columns, err := table.GetKeyColumns()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(columns).To(HaveLen(1))
+				Expect(columns[0].Name).To(Equal("code"))
+			}
+		})
+	})
+
+	It("reads the primary keys of the shared tables", func() {
+		for _, db := range []pg2mysql.DB{pg, mysql} {
+			schema, err := pg2mysql.BuildSchema(db)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(schema.Tables["table_with_id"].PrimaryKey).To(Equal([]string{"id"}))
+			Expect(schema.Tables["table_with_string_id"].PrimaryKey).To(Equal([]string{"id"}))
+			Expect(schema.Tables["table_without_id"].PrimaryKey).To(BeNil())
+		}
+	})
+
+	Context("when a table has an id column but no primary key or unique index", func() {
+		BeforeEach(func() {
+			_, err := pgRunner.DB().Exec("CREATE TABLE table_with_unkeyed_id (id integer, name text)")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_unkeyed_id (id int, name varchar(255))")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, err := pgRunner.DB().Exec("DROP TABLE table_with_unkeyed_id")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_unkeyed_id")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("treats the table as having no key", func() {
+			for _, db := range []pg2mysql.DB{pg, mysql} {
+				schema, err := pg2mysql.BuildSchema(db)
+				Expect(err).NotTo(HaveOccurred())
+
+				table := schema.Tables["table_with_unkeyed_id"]
+				Expect(table.PrimaryKey).To(BeNil())
+				Expect(table.HasKey()).To(BeFalse())
+
+				_, _, err = table.GetKeyColumns()
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Context("when a table has a unique index instead of a primary key", func() {
+		BeforeEach(func() {
+			_, err := pgRunner.DB().Exec(`
+			CREATE TABLE table_with_unique_index (
+				nullable_code text UNIQUE,
+				code text NOT NULL UNIQUE
+			)`)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, err := pgRunner.DB().Exec("DROP TABLE table_with_unique_index")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("uses the unique index on NOT NULL columns as the key", func() {
+			schema, err := pg2mysql.BuildSchema(pg)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(schema.Tables["table_with_unique_index"].PrimaryKey).To(Equal([]string{"code"}))
+		})
+	})
+})
@@ -0,0 +1,552 @@
+package pg2mysql
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxIndexedChars is the longest utf8mb4 column that fits in an InnoDB
+	// index key of 3072 bytes.
+	maxIndexedChars = 768
+
+	// maxVarcharChars is the longest utf8mb4 varchar that fits in a row.
+	maxVarcharChars = 16383
+
+	// maxRowBytes is the most bytes that the columns of a MySQL row may
+	// take, counting text and blob columns, which are stored apart from the
+	// row, by their pointers only.
+	maxRowBytes = 65535
+)
+
+// WriteMySQLSchema writes a CREATE TABLE statement for each table to w,
+// naming the tables as schemas maps them. Anything that could not be
+// translated exactly is described in a comment above the statement of its
+// table.
+func WriteMySQLSchema(w io.Writer, tables []*TableDefinition, schemas SchemaMap) error {
+	var b bytes.Buffer
+	b.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n\n")
+
+	for _, table := range tables {
+		stmt, notes, err := MySQLCreateTable(table, schemas)
+		if err != nil {
+			return err
+		}
+
+		for _, note := range notes {
+			fmt.Fprintf(&b, "-- %s\n", note)
+		}
+		fmt.Fprintf(&b, "%s;\n\n", stmt)
+	}
+
+	b.WriteString("SET FOREIGN_KEY_CHECKS = 1;\n")
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// MySQLCreateTable returns the CREATE TABLE statement for table, named as
+// schemas maps it, along with notes on anything that could not be
+// translated exactly. It returns an
+// error if a column is too long to be part of a primary key, unique index
+// or foreign key, which MySQL would refuse to create; non-unique indexes
+// index a prefix of such columns instead.
+func MySQLCreateTable(table *TableDefinition, schemas SchemaMap) (string, []string, error) {
+	var notes []string
+	note := func(format string, args ...interface{}) {
+		notes = append(notes, fmt.Sprintf("%s: %s", table.Name, fmt.Sprintf(format, args...)))
+	}
+
+	indexed := map[string]bool{}
+	leading := map[string]bool{}
+	keys := [][]string{table.PrimaryKey}
+	for _, index := range table.Indexes {
+		if !index.Unsupported {
+			keys = append(keys, index.Columns)
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		keys = append(keys, fk.Columns)
+	}
+	for _, key := range keys {
+		for i, column := range key {
+			indexed[column] = true
+			if i == 0 {
+				leading[column] = true
+			}
+		}
+	}
+
+	types := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		typ, typeNote := mysqlColumnType(column, indexed[column.Name])
+		if typeNote != "" {
+			note("%s: %s", column.Name, typeNote)
+		}
+		types[i] = typ
+	}
+
+	// MySQL refuses rows whose columns could take more than maxRowBytes, so
+	// the longest varchar columns that are not indexed become text until
+	// the row fits
+	rowBytes := int64(0)
+	for _, typ := range types {
+		rowBytes += columnBytes(typ)
+	}
+	for rowBytes > maxRowBytes {
+		longest := -1
+		for i, typ := range types {
+			if !strings.HasPrefix(typ, "varchar(") || indexed[table.Columns[i].Name] {
+				continue
+			}
+			if longest < 0 || columnBytes(typ) > columnBytes(types[longest]) {
+				longest = i
+			}
+		}
+		if longest < 0 {
+			break
+		}
+
+		typ := textTypeForBytes(columnBytes(types[longest]))
+		note("%s: %s stored as %s to fit a row in %d bytes", table.Columns[longest].Name, types[longest], typ, maxRowBytes)
+		rowBytes += columnBytes(typ) - columnBytes(types[longest])
+		types[longest] = typ
+	}
+
+	var lines []string
+	var autoIncrement bool
+	prefixes := map[string]int64{}
+	for i, column := range table.Columns {
+		typ := types[i]
+
+		if indexed[column.Name] {
+			if prefix, ok := indexPrefix(typ); ok {
+				prefixes[column.Name] = prefix
+			}
+		}
+
+		line := fmt.Sprintf("%s %s", quoteIdentifier(column.Name), typ)
+		if !column.Nullable {
+			line += " NOT NULL"
+		}
+
+		// MySQL allows a single AUTO_INCREMENT column, which must lead a key
+		if isSerial(column) && !autoIncrement && leading[column.Name] {
+			autoIncrement = true
+			line += " AUTO_INCREMENT"
+		} else if isSerial(column) {
+			note("%s: not made AUTO_INCREMENT as it does not lead a key or another column already is", column.Name)
+		} else if column.Default != nil {
+			def, defaultNote := mysqlDefault(column, typ)
+			if def != "" {
+				line += " DEFAULT " + def
+			}
+			if defaultNote != "" {
+				note("%s: %s", column.Name, defaultNote)
+			}
+		}
+
+		if column.Comment != "" {
+			line += " COMMENT " + quoteString(column.Comment)
+		}
+
+		lines = append(lines, line)
+	}
+
+	tooLong := func(key string, columns []string) error {
+		for _, column := range columns {
+			if _, ok := prefixes[column]; ok {
+				return fmt.Errorf("%s: %s is longer than an index key can hold and cannot be part of %s", table.Name, column, key)
+			}
+		}
+		return nil
+	}
+
+	if len(table.PrimaryKey) > 0 {
+		if err := tooLong("the primary key", table.PrimaryKey); err != nil {
+			return "", nil, err
+		}
+		lines = append(lines, fmt.Sprintf("PRIMARY KEY (%s)", quoteIdentifiers(table.PrimaryKey)))
+	}
+
+	for _, index := range table.Indexes {
+		if index.Unsupported {
+			note("index %s is partial or on an expression and was skipped", index.Name)
+			continue
+		}
+
+		if index.Unique {
+			if err := tooLong("unique index "+index.Name, index.Columns); err != nil {
+				return "", nil, err
+			}
+			lines = append(lines, fmt.Sprintf("UNIQUE KEY %s (%s)", quoteIdentifier(index.Name), quoteIdentifiers(index.Columns)))
+			continue
+		}
+
+		parts := make([]string, len(index.Columns))
+		for i, column := range index.Columns {
+			parts[i] = quoteIdentifier(column)
+			if prefix, ok := prefixes[column]; ok {
+				parts[i] = fmt.Sprintf("%s(%d)", parts[i], prefix)
+				note("index %s: only indexes the first %d characters of %s", index.Name, prefix, column)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("KEY %s (%s)", quoteIdentifier(index.Name), strings.Join(parts, ",")))
+	}
+
+	for _, fk := range table.ForeignKeys {
+		if err := tooLong("foreign key "+fk.Name, fk.Columns); err != nil {
+			return "", nil, err
+		}
+
+		line := fmt.Sprintf(
+			"CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			quoteIdentifier(fk.Name),
+			quoteIdentifiers(fk.Columns),
+			mysqlTableIdentifier(schemas, fk.RefTable),
+			quoteIdentifiers(fk.RefColumns),
+		)
+
+		for _, action := range []struct{ event, action string }{
+			{"DELETE", fk.OnDelete},
+			{"UPDATE", fk.OnUpdate},
+		} {
+			switch action.action {
+			case "", "NO ACTION":
+			case "SET DEFAULT":
+				note("foreign key %s: ON %s SET DEFAULT is not supported by InnoDB and was dropped", fk.Name, action.event)
+			default:
+				line += fmt.Sprintf(" ON %s %s", action.event, action.action)
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	stmt := fmt.Sprintf(
+		"CREATE TABLE %s (\n  %s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		mysqlTableIdentifier(schemas, table.Name),
+		strings.Join(lines, ",\n  "),
+	)
+
+	if table.Comment != "" {
+		stmt += " COMMENT=" + quoteString(table.Comment)
+	}
+
+	return stmt, notes, nil
+}
+
+// sizedType matches a MySQL type and its length, if it has one.
+var sizedType = regexp.MustCompile(`^(\w+)(?:\((\d+)(?:,\d+)?\))?`)
+
+// columnBytes returns the most bytes a column of type typ takes in a row,
+// with character columns in utf8mb4. Text and blob columns only take the
+// bytes of their pointers.
+func columnBytes(typ string) int64 {
+	match := sizedType.FindStringSubmatch(typ)
+	if match == nil {
+		return 8
+	}
+
+	length, _ := strconv.ParseInt(match[2], 10, 64)
+	switch match[1] {
+	case "varchar", "varbinary":
+		if match[1] == "varchar" {
+			length *= 4
+		}
+		if length > 255 {
+			return length + 2
+		}
+		return length + 1
+	case "char":
+		return length * 4
+	case "decimal":
+		return length/2 + 1
+	case "tinyint", "smallint", "enum":
+		return 2
+	case "int", "float":
+		return 4
+	case "date":
+		return 3
+	case "time":
+		return 6
+	case "tinytext", "text", "mediumtext", "longtext", "blob", "mediumblob", "longblob", "json":
+		return 12
+	default:
+		return 8
+	}
+}
+
+// longIndexedType matches the types given to indexed columns that may be
+// too long for an index key.
+var longIndexedType = regexp.MustCompile(`^(varchar|varbinary)\((\d+)\)$`)
+
+// indexPrefix returns the length of the longest prefix of a column of type
+// typ that fits in an index key, if the whole column does not.
+func indexPrefix(typ string) (int64, bool) {
+	match := longIndexedType.FindStringSubmatch(typ)
+	if match == nil {
+		return 0, false
+	}
+
+	length, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	limit := int64(maxIndexedChars)
+	if match[1] == "varbinary" {
+		limit = maxIndexedChars * 4
+	}
+
+	if length <= limit {
+		return 0, false
+	}
+
+	return limit, true
+}
+
+// mysqlColumnType returns the MySQL type for column, and a note if the type
+// is not an exact equivalent. Indexed character and binary columns are
+// given types that fit in an index key.
+func mysqlColumnType(column *ColumnDefinition, indexed bool) (string, string) {
+	if len(column.EnumValues) > 0 {
+		values := make([]string, len(column.EnumValues))
+		for i, value := range column.EnumValues {
+			values[i] = quoteString(value)
+		}
+		return fmt.Sprintf("enum(%s)", strings.Join(values, ",")), ""
+	}
+
+	switch column.DataType {
+	case "smallint":
+		return "smallint", ""
+	case "integer":
+		return "int", ""
+	case "bigint":
+		return "bigint", ""
+	case "boolean":
+		return "tinyint(1)", ""
+	case "real":
+		return "float", ""
+	case "double precision":
+		return "double", ""
+	case "money":
+		return "decimal(19,2)", ""
+	case "numeric":
+		return decimalType(column.NumericPrecision, column.NumericScale)
+	case "character varying":
+		if column.MaxChars > 0 {
+			return varcharType(column.MaxChars, indexed)
+		}
+		return textType(column, indexed)
+	case "character":
+		if column.MaxChars <= 255 {
+			return fmt.Sprintf("char(%d)", column.MaxChars), ""
+		}
+		return varcharType(column.MaxChars, indexed)
+	case "text":
+		return textType(column, indexed)
+	case "uuid":
+		return "char(36)", ""
+	case "bytea":
+		return blobType(column, indexed)
+	case "date":
+		return "date", ""
+	case "timestamp without time zone", "timestamp with time zone":
+		return withFraction("datetime", column.DatetimePrecision), ""
+	case "time without time zone", "time with time zone":
+		return withFraction("time", column.DatetimePrecision), ""
+	case "json", "jsonb":
+		return "json", ""
+	case "inet", "cidr":
+		return "varchar(43)", ""
+	case "macaddr":
+		return "varchar(17)", ""
+	case "interval":
+		return "varchar(255)", "interval is stored as text"
+	case "USER-DEFINED":
+		if column.UDTName == "citext" {
+			return textType(column, indexed)
+		}
+	}
+
+	return "longtext", fmt.Sprintf("%s has no MySQL equivalent and is stored as text", column.UDTName)
+}
+
+func decimalType(precision, scale int64) (string, string) {
+	if precision == 0 {
+		return "decimal(65,30)", "numeric without a precision may not fit decimal(65,30)"
+	}
+
+	var notes []string
+	if precision > 65 {
+		precision = 65
+		notes = append(notes, "precision reduced to 65")
+	}
+	if scale > 30 {
+		scale = 30
+		notes = append(notes, "scale reduced to 30")
+	}
+
+	return fmt.Sprintf("decimal(%d,%d)", precision, scale), strings.Join(notes, ", ")
+}
+
+func varcharType(chars int64, indexed bool) (string, string) {
+	if indexed {
+		return fmt.Sprintf("varchar(%d)", chars), ""
+	}
+
+	if chars > maxVarcharChars {
+		return textTypeForBytes(chars * 4), ""
+	}
+
+	return fmt.Sprintf("varchar(%d)", chars), ""
+}
+
+// textType returns the type for an unbounded character column. Indexed
+// columns become varchar; others are sized from the observed data if it
+// has been measured, or made longtext if not.
+func textType(column *ColumnDefinition, indexed bool) (string, string) {
+	if indexed {
+		chars := int64(255)
+		if column.ObservedMaxChars != nil && *column.ObservedMaxChars > chars {
+			chars = *column.ObservedMaxChars
+		}
+		return varcharType(chars, true)
+	}
+
+	if column.ObservedMaxChars == nil {
+		return "longtext", ""
+	}
+
+	if *column.ObservedMaxChars <= 255 {
+		return "varchar(255)", ""
+	}
+
+	return textTypeForBytes(*column.ObservedMaxBytes), ""
+}
+
+func textTypeForBytes(bytes int64) string {
+	switch {
+	case bytes <= 65535:
+		return "text"
+	case bytes <= 16777215:
+		return "mediumtext"
+	default:
+		return "longtext"
+	}
+}
+
+func blobType(column *ColumnDefinition, indexed bool) (string, string) {
+	if indexed {
+		bytes := int64(255)
+		if column.ObservedMaxBytes != nil && *column.ObservedMaxBytes > bytes {
+			bytes = *column.ObservedMaxBytes
+		}
+		return fmt.Sprintf("varbinary(%d)", bytes), ""
+	}
+
+	if column.ObservedMaxBytes == nil {
+		return "longblob", ""
+	}
+
+	switch bytes := *column.ObservedMaxBytes; {
+	case bytes <= 65535:
+		return "blob", ""
+	case bytes <= 16777215:
+		return "mediumblob", ""
+	default:
+		return "longblob", ""
+	}
+}
+
+func withFraction(typ string, precision int64) string {
+	if precision <= 0 {
+		return typ
+	}
+	if precision > 6 {
+		precision = 6
+	}
+	return fmt.Sprintf("%s(%d)", typ, precision)
+}
+
+func isSerial(column *ColumnDefinition) bool {
+	return column.Identity || (column.Default != nil && strings.HasPrefix(*column.Default, "nextval("))
+}
+
+var (
+	stringDefault    = regexp.MustCompile(`^'((?:[^']|'')*)'(?:::[\w ."]+(?:\[\])?)*$`)
+	numericDefault   = regexp.MustCompile(`^\(?(-?\d+(?:\.\d+)?)\)?(?:::[\w ."]+)*$`)
+	nullDefault      = regexp.MustCompile(`^NULL(?:::[\w ."]+)*$`)
+	timestampDefault = regexp.MustCompile(`^(?:now\(\)|CURRENT_TIMESTAMP(?:\(\d\))?|LOCALTIMESTAMP(?:\(\d\))?|transaction_timestamp\(\)|statement_timestamp\(\))$`)
+)
+
+// mysqlDefault translates the default of column to MySQL, returning an
+// empty default and a note if it cannot be translated.
+func mysqlDefault(column *ColumnDefinition, typ string) (string, string) {
+	def := *column.Default
+
+	if nullDefault.MatchString(def) {
+		return "", ""
+	}
+
+	if strings.HasSuffix(typ, "text") || strings.HasSuffix(typ, "blob") || typ == "json" {
+		return "", fmt.Sprintf("default %s dropped: MySQL does not allow defaults on %s columns", def, typ)
+	}
+
+	switch {
+	case def == "true":
+		return "1", ""
+	case def == "false":
+		return "0", ""
+	case timestampDefault.MatchString(def) && strings.HasPrefix(typ, "datetime"):
+		return strings.Replace(typ, "datetime", "CURRENT_TIMESTAMP", 1), ""
+	}
+
+	if m := stringDefault.FindStringSubmatch(def); m != nil {
+		return quoteString(strings.Replace(m[1], "''", "'", -1)), ""
+	}
+
+	if m := numericDefault.FindStringSubmatch(def); m != nil {
+		return m[1], ""
+	}
+
+	return "", fmt.Sprintf("default %s could not be translated", def)
+}
+
+// mysqlTableIdentifier returns the quoted MySQL name of the table with the
+// given qualified name, as schemas maps it, for statements run in the
+// database being migrated to: qualified by its database unless it is
+// stored in that one.
+func mysqlTableIdentifier(schemas SchemaMap, name string) string {
+	schema, table := splitTableName(name)
+	database, prefix := schemas.target(schema, "")
+	if database == "" {
+		return quoteIdentifier(prefix + table)
+	}
+
+	return quoteIdentifier(database) + "." + quoteIdentifier(prefix+table)
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ",")
+}
+
+func quoteString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "'", "''", -1)
+	return "'" + s + "'"
+}
@@ -0,0 +1,290 @@
+package pg2mysql_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("Schema generation", func() {
+	var pg pg2mysql.DB
+
+	BeforeEach(func() {
+		pg = pg2mysql.NewPostgreSQLDB(
+			pgRunner.DBName,
+			"",
+			"",
+			"127.0.0.1",
+			5432,
+			"disable",
+			nil,
+			pg2mysql.TableFilter{},
+		)
+		err := pg.Open()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = pgRunner.DB().Exec(`
+		CREATE TABLE ddl_parent (id integer PRIMARY KEY);
+		CREATE TABLE ddl_example (
+			id serial PRIMARY KEY,
+			parent_id integer REFERENCES ddl_parent (id) ON DELETE CASCADE,
+			code varchar(20) NOT NULL UNIQUE,
+			price numeric(10,2) DEFAULT 0,
+			label text DEFAULT 'it''s',
+			active boolean NOT NULL DEFAULT true,
+			updated_at timestamp DEFAULT now()
+		);
+		COMMENT ON TABLE ddl_example IS 'an example';
+		COMMENT ON COLUMN ddl_example.code IS 'short code';
+		INSERT INTO ddl_example (code, label) VALUES ('a', 'some label')`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_, err := pgRunner.DB().Exec("DROP TABLE ddl_example, ddl_parent")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = mysqlRunner.DB().Exec("DROP TABLE IF EXISTS ddl_example, ddl_parent")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = pg.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	getTable := func(tables []*pg2mysql.TableDefinition, name string) *pg2mysql.TableDefinition {
+		for _, table := range tables {
+			if table.Name == name {
+				return table
+			}
+		}
+		return nil
+	}
+
+	It("reads the table definitions from the catalog", func() {
+		tables, err := pg2mysql.ReadTableDefinitions(pg)
+		Expect(err).NotTo(HaveOccurred())
+
+		table := getTable(tables, "ddl_example")
+		Expect(table).NotTo(BeNil())
+		Expect(table.Comment).To(Equal("an example"))
+		Expect(table.PrimaryKey).To(Equal([]string{"id"}))
+		Expect(table.Indexes).To(ConsistOf(&pg2mysql.IndexDefinition{
+			Name:    "ddl_example_code_key",
+			Unique:  true,
+			Columns: []string{"code"},
+		}))
+		Expect(table.ForeignKeys).To(ConsistOf(&pg2mysql.ForeignKeyDefinition{
+			Name:       "ddl_example_parent_id_fkey",
+			Columns:    []string{"parent_id"},
+			RefTable:   "ddl_parent",
+			RefColumns: []string{"id"},
+			OnUpdate:   "NO ACTION",
+			OnDelete:   "CASCADE",
+		}))
+		Expect(table.GetColumn("code").Comment).To(Equal("short code"))
+		Expect(table.GetColumn("price").NumericPrecision).To(BeNumerically("==", 10))
+	})
+
+	It("generates a schema that MySQL accepts", func() {
+		tables, err := pg2mysql.ReadTableDefinitions(pg)
+		Expect(err).NotTo(HaveOccurred())
+
+		stmt, notes, err := pg2mysql.MySQLCreateTable(getTable(tables, "ddl_example"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(ConsistOf("ddl_example: label: default 'it''s'::text dropped: MySQL does not allow defaults on longtext columns"))
+		Expect(stmt).To(ContainSubstring("`id` int NOT NULL AUTO_INCREMENT"))
+		Expect(stmt).To(ContainSubstring("`code` varchar(20) NOT NULL COMMENT 'short code'"))
+		Expect(stmt).To(ContainSubstring("`price` decimal(10,2) DEFAULT 0"))
+		Expect(stmt).To(ContainSubstring("`label` longtext"))
+		Expect(stmt).To(ContainSubstring("`active` tinyint(1) NOT NULL DEFAULT 1"))
+		Expect(stmt).To(ContainSubstring("`updated_at` datetime(6) DEFAULT CURRENT_TIMESTAMP(6)"))
+		Expect(stmt).To(ContainSubstring("UNIQUE KEY `ddl_example_code_key` (`code`)"))
+		Expect(stmt).To(ContainSubstring("CONSTRAINT `ddl_example_parent_id_fkey` FOREIGN KEY (`parent_id`) REFERENCES `ddl_parent` (`id`) ON DELETE CASCADE"))
+
+		_, err = mysqlRunner.DB().Exec("CREATE TABLE ddl_parent (id int PRIMARY KEY)")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = mysqlRunner.DB().Exec(stmt)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("sizes text columns from the data when asked to", func() {
+		tables, err := pg2mysql.ReadTableDefinitions(pg)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = pg2mysql.SizeColumns(pg, tables)
+		Expect(err).NotTo(HaveOccurred())
+
+		var b bytes.Buffer
+		err = pg2mysql.WriteMySQLSchema(&b, []*pg2mysql.TableDefinition{getTable(tables, "ddl_example")}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.String()).To(ContainSubstring("`label` varchar(255) DEFAULT 'it''s'"))
+	})
+
+	Context("when an indexed column is longer than an index key can hold", func() {
+		var table *pg2mysql.TableDefinition
+
+		BeforeEach(func() {
+			table = &pg2mysql.TableDefinition{
+				Name: "ddl_long_index",
+				Columns: []*pg2mysql.ColumnDefinition{
+					{Name: "id", DataType: "integer"},
+					{Name: "url", DataType: "character varying", MaxChars: 2000, Nullable: true},
+				},
+				PrimaryKey: []string{"id"},
+				Indexes: []*pg2mysql.IndexDefinition{
+					{Name: "ddl_long_index_url", Columns: []string{"url"}},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			_, err := mysqlRunner.DB().Exec("DROP TABLE IF EXISTS ddl_long_index")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("indexes a prefix of the column", func() {
+			stmt, notes, err := pg2mysql.MySQLCreateTable(table, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(ConsistOf("ddl_long_index: index ddl_long_index_url: only indexes the first 768 characters of url"))
+			Expect(stmt).To(ContainSubstring("`url` varchar(2000)"))
+			Expect(stmt).To(ContainSubstring("KEY `ddl_long_index_url` (`url`(768))"))
+
+			_, err = mysqlRunner.DB().Exec(stmt)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns an error if the index is unique", func() {
+			table.Indexes[0].Unique = true
+
+			_, _, err := pg2mysql.MySQLCreateTable(table, nil)
+			Expect(err).To(MatchError("ddl_long_index: url is longer than an index key can hold and cannot be part of unique index ddl_long_index_url"))
+		})
+	})
+
+	It("notes every reduction of a numeric column", func() {
+		table := &pg2mysql.TableDefinition{
+			Name: "ddl_numeric",
+			Columns: []*pg2mysql.ColumnDefinition{
+				{Name: "amount", DataType: "numeric", NumericPrecision: 100, NumericScale: 40},
+			},
+		}
+
+		stmt, notes, err := pg2mysql.MySQLCreateTable(table, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(notes).To(ConsistOf("ddl_numeric: amount: precision reduced to 65, scale reduced to 30"))
+		Expect(stmt).To(ContainSubstring("`amount` decimal(65,30) NOT NULL"))
+	})
+
+	Context("when the columns of a table would not fit in a MySQL row", func() {
+		var table *pg2mysql.TableDefinition
+
+		BeforeEach(func() {
+			table = &pg2mysql.TableDefinition{
+				Name: "ddl_wide",
+				Columns: []*pg2mysql.ColumnDefinition{
+					{Name: "id", DataType: "integer"},
+					{Name: "a", DataType: "character varying", MaxChars: 10000, Nullable: true},
+					{Name: "b", DataType: "character varying", MaxChars: 10000, Nullable: true},
+					{Name: "c", DataType: "character varying", MaxChars: 12000, Nullable: true},
+					{Name: "d", DataType: "character varying", MaxChars: 10000, Nullable: true},
+					{Name: "e", DataType: "character varying", MaxChars: 10000, Nullable: true},
+				},
+				PrimaryKey: []string{"id"},
+			}
+		})
+
+		AfterEach(func() {
+			_, err := mysqlRunner.DB().Exec("DROP TABLE IF EXISTS ddl_wide")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("stores the longest varchar columns as text", func() {
+			stmt, notes, err := pg2mysql.MySQLCreateTable(table, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notes).To(ConsistOf(
+				"ddl_wide: c: varchar(12000) stored as text to fit a row in 65535 bytes",
+				"ddl_wide: a: varchar(10000) stored as text to fit a row in 65535 bytes",
+				"ddl_wide: b: varchar(10000) stored as text to fit a row in 65535 bytes",
+				"ddl_wide: d: varchar(10000) stored as text to fit a row in 65535 bytes",
+			))
+			Expect(stmt).To(ContainSubstring("`e` varchar(10000)"))
+
+			_, err = mysqlRunner.DB().Exec(stmt)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when tables are in several schemas", func() {
+		var schemas pg2mysql.SchemaMap
+
+		BeforeEach(func() {
+			_, err := pgRunner.DB().Exec(`
+			CREATE SCHEMA ddl_audit;
+			CREATE TYPE ddl_audit.action AS ENUM ('insert', 'delete');
+			CREATE TABLE ddl_audit.entries (
+				id integer PRIMARY KEY,
+				parent_id integer REFERENCES ddl_parent (id),
+				action ddl_audit.action NOT NULL
+			)`)
+			Expect(err).NotTo(HaveOccurred())
+
+			schemas = pg2mysql.SchemaMap{
+				"public":    {},
+				"ddl_audit": {TablePrefix: "audit_"},
+			}
+		})
+
+		AfterEach(func() {
+			_, err := pgRunner.DB().Exec("DROP SCHEMA ddl_audit CASCADE")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		readTables := func() []*pg2mysql.TableDefinition {
+			db := pg2mysql.NewPostgreSQLDB(
+				pgRunner.DBName,
+				"",
+				"",
+				"127.0.0.1",
+				5432,
+				"disable",
+				schemas,
+				pg2mysql.TableFilter{},
+			)
+			Expect(db.Open()).To(Succeed())
+			defer db.Close()
+
+			tables, err := pg2mysql.ReadTableDefinitions(db)
+			Expect(err).NotTo(HaveOccurred())
+			return tables
+		}
+
+		It("reads the tables of every schema", func() {
+			tables := readTables()
+			Expect(getTable(tables, "ddl_example")).NotTo(BeNil())
+
+			table := getTable(tables, "ddl_audit.entries")
+			Expect(table).NotTo(BeNil())
+			Expect(table.Schema).To(Equal("ddl_audit"))
+			Expect(table.PrimaryKey).To(Equal([]string{"id"}))
+			Expect(table.GetColumn("action").EnumValues).To(Equal([]string{"insert", "delete"}))
+			Expect(table.ForeignKeys).To(HaveLen(1))
+			Expect(table.ForeignKeys[0].RefTable).To(Equal("ddl_parent"))
+		})
+
+		It("names the tables as they are mapped", func() {
+			stmt, _, err := pg2mysql.MySQLCreateTable(getTable(readTables(), "ddl_audit.entries"), schemas)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stmt).To(HavePrefix("CREATE TABLE `audit_entries` ("))
+			Expect(stmt).To(ContainSubstring("REFERENCES `ddl_parent` (`id`)"))
+		})
+
+		It("qualifies the tables of schemas stored in another database", func() {
+			schemas["ddl_audit"] = pg2mysql.SchemaTarget{}
+
+			stmt, _, err := pg2mysql.MySQLCreateTable(getTable(readTables(), "ddl_audit.entries"), schemas)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stmt).To(HavePrefix("CREATE TABLE `ddl_audit`.`entries` ("))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package pg2mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ForeignKeyViolation describes the rows of a table that would violate a
+// foreign key defined in MySQL, because the row they reference does not
+// exist in PostgreSQL. RowIDs is only set for tables with a key.
+type ForeignKeyViolation struct {
+	Constraint      string
+	ReferencedTable string
+	RowIDs          []RowKey
+	RowCount        int64
+}
+
+func (v ForeignKeyViolation) String() string {
+	if len(v.RowIDs) > 0 {
+		return fmt.Sprintf("%d rows reference missing rows of %s in foreign key %s: IDs %v", v.RowCount, v.ReferencedTable, v.Constraint, v.RowIDs)
+	}
+	return fmt.Sprintf("%d rows reference missing rows of %s in foreign key %s", v.RowCount, v.ReferencedTable, v.Constraint)
+}
+
+// readMySQLForeignKeys returns the foreign keys of each migrated table in
+// MySQL that reference migrated tables, along with the collations of the
+// referenced columns.
+func readMySQLForeignKeys(db DB) (map[string][]*ForeignKeyDefinition, error) {
+	condition, args := mysqlSchemaCondition(db, "k.table_schema")
+	query := `
+	SELECT k.table_schema,
+	       k.table_name,
+	       k.constraint_name,
+	       k.column_name,
+	       k.referenced_table_schema,
+	       k.referenced_table_name,
+	       k.referenced_column_name,
+	       r.update_rule,
+	       r.delete_rule,
+	       c.collation_name
+	FROM   information_schema.key_column_usage k
+	       JOIN information_schema.referential_constraints r
+	         ON r.constraint_schema = k.constraint_schema
+	            AND r.table_name = k.table_name
+	            AND r.constraint_name = k.constraint_name
+	       LEFT JOIN information_schema.columns c
+	         ON c.table_schema = k.referenced_table_schema
+	            AND c.table_name = k.referenced_table_name
+	            AND c.column_name = k.referenced_column_name
+	WHERE  ` + condition + `
+	ORDER  BY k.table_schema,
+	          k.table_name,
+	          k.constraint_name,
+	          k.ordinal_position`
+	rows, err := db.Querier().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeys := map[string][]*ForeignKeyDefinition{}
+	var fk *ForeignKeyDefinition
+	var fkTable string
+	for rows.Next() {
+		var tableSchema, tableName, name, column, refSchema, refTableName, refColumn, onUpdate, onDelete string
+		var collation sql.NullString
+		err := rows.Scan(&tableSchema, &tableName, &name, &column, &refSchema, &refTableName, &refColumn, &onUpdate, &onDelete, &collation)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		table, ok := db.TableName(tableSchema, tableName)
+		if !ok {
+			continue
+		}
+
+		refTable, ok := db.TableName(refSchema, refTableName)
+		if !ok {
+			continue
+		}
+
+		// constraint names are only unique within a table
+		if fk == nil || fk.Name != name || fkTable != table {
+			fkTable = table
+			fk = &ForeignKeyDefinition{
+				Name:     name,
+				RefTable: refTable,
+				OnUpdate: onUpdate,
+				OnDelete: onDelete,
+			}
+			foreignKeys[table] = append(foreignKeys[table], fk)
+		}
+
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+		fk.refCollations = append(fk.refCollations, collation.String)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return foreignKeys, rows.Close()
+}
+
+// readReferencedTables returns the migrated tables in MySQL that are
+// referenced by a foreign key of another table, migrated or not.
+func readReferencedTables(db DB) (map[string]bool, error) {
+	condition, args := mysqlSchemaCondition(db, "referenced_table_schema")
+	query := `
+	SELECT DISTINCT table_schema,
+	                table_name,
+	                referenced_table_schema,
+	                referenced_table_name
+	FROM   information_schema.key_column_usage
+	WHERE  ` + condition + `
+	       AND referenced_table_name IS NOT NULL`
+	rows, err := db.Querier().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]bool{}
+	for rows.Next() {
+		var tableSchema, tableName, refSchema, refTableName string
+		if err := rows.Scan(&tableSchema, &tableName, &refSchema, &refTableName); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if tableSchema == refSchema && tableName == refTableName {
+			continue
+		}
+
+		if refTable, ok := db.TableName(refSchema, refTableName); ok {
+			referenced[refTable] = true
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return referenced, rows.Close()
+}
+
+// getForeignKeyViolations returns the rows of src that reference a row
+// missing from schema through one of foreignKeys, the foreign keys of the
+// matching MySQL table. As in MySQL, rows with a NULL in any
+// of the columns of a foreign key are not checked, and string columns are
+// compared under the collations of the referenced columns. Foreign keys whose
+// columns or referenced table are missing from the source are skipped.
+func getForeignKeyViolations(db DB, schema *Schema, src *Table, foreignKeys []*ForeignKeyDefinition) ([]ForeignKeyViolation, error) {
+	var violations []ForeignKeyViolation
+
+foreignKeys:
+	for _, fk := range foreignKeys {
+		referenced, err := schema.GetTable(fk.RefTable)
+		if err != nil {
+			continue
+		}
+
+		notNull := make([]string, len(fk.Columns))
+		matches := make([]string, len(fk.Columns))
+		for i, column := range fk.Columns {
+			if !src.HasColumn(column) || !referenced.HasColumn(fk.RefColumns[i]) {
+				continue foreignKeys
+			}
+
+			notNull[i] = fmt.Sprintf("%s.%s IS NOT NULL", src.Name, column)
+			parentValue := fmt.Sprintf("p.%s", fk.RefColumns[i])
+			childValue := fmt.Sprintf("%s.%s", src.Name, column)
+			if i < len(fk.refCollations) && fk.refCollations[i] != "" {
+				parentValue = collatedValue(parentValue, fk.refCollations[i], 0)
+				childValue = collatedValue(childValue, fk.refCollations[i], 0)
+			}
+			matches[i] = fmt.Sprintf("%s = %s", parentValue, childValue)
+		}
+
+		// the referenced table is aliased so that keys referencing their own
+		// table still compare against the outer row
+		where := fmt.Sprintf(
+			"%s AND NOT EXISTS (SELECT 1 FROM %s p WHERE %s)",
+			strings.Join(notNull, " AND "), referenced.Name, strings.Join(matches, " AND "),
+		)
+
+		violation := ForeignKeyViolation{
+			Constraint:      fk.Name,
+			ReferencedTable: fk.RefTable,
+		}
+
+		if src.HasKey() {
+			rowIDs, err := selectRowKeys(db, src, where)
+			if err != nil {
+				return nil, fmt.Errorf("failed checking foreign key %s: %s", fk.Name, err)
+			}
+			violation.RowIDs = rowIDs
+			violation.RowCount = int64(len(rowIDs))
+		} else {
+			stmt := fmt.Sprintf("SELECT count(1) FROM %s WHERE %s", src.Name, where)
+			if err := db.Querier().QueryRow(stmt).Scan(&violation.RowCount); err != nil {
+				return nil, fmt.Errorf("failed checking foreign key %s: %s", fk.Name, err)
+			}
+		}
+
+		if violation.RowCount > 0 {
+			violations = append(violations, violation)
+		}
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,59 @@
+package pg2mysql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InfinityMapping maps the special PostgreSQL date and time values
+// "infinity" and "-infinity" to the values stored in MySQL in their place:
+// a time.Time, or nil to store NULL. Values that are not mapped are copied
+// as they are, which MySQL rejects.
+type InfinityMapping map[string]interface{}
+
+// ParseInfinityValue parses the value that an infinite date or time should
+// be stored as: a date, a date and time, or "null".
+func ParseInfinityValue(s string) (interface{}, error) {
+	if strings.EqualFold(s, "null") {
+		return nil, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected a date, a date and time or null, got %q", s)
+}
+
+// apply replaces the infinite values in the date and time columns of a row
+// of table.
+func (m InfinityMapping) apply(table *Table, values []interface{}) {
+	if len(m) == 0 {
+		return
+	}
+
+	for i, column := range table.Columns {
+		switch typeFamilies[column.Type] {
+		case "datetime", "date":
+		default:
+			continue
+		}
+
+		var s string
+		switch v := values[i].(type) {
+		case []byte:
+			s = string(v)
+		case string:
+			s = v
+		default:
+			continue
+		}
+
+		if value, ok := m[s]; ok {
+			values[i] = value
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package pg2mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBatchRows = 1000
+
+	// maxPlaceholders is the most placeholders MySQL accepts in a single
+	// prepared statement.
+	maxPlaceholders = 65535
+
+	// packetHeadroom is left free in max_allowed_packet for the packet header
+	// and the per-value length prefixes of the binary protocol.
+	packetHeadroom = 16 * 1024
+)
+
+// batchInserter buffers rows and writes them to a table with multi-row
+// INSERT statements. A batch that fails is bisected until the offending rows
+// are isolated, so that a single bad row does not prevent the rest of its
+// batch from being inserted.
+type batchInserter struct {
+	db        *sql.DB
+	table     *Table
+	tableName string
+	columns   string
+	maxRows   int
+	maxSize   int
+
+	rows [][]interface{}
+	size int
+
+	// afterFlush, if set, is called with the last row of each flushed batch.
+	afterFlush func(lastRow []interface{}) error
+
+	// nullColumns holds the indexes of the columns inserted as NULL.
+	nullColumns []int
+}
+
+func newBatchInserter(db DB, table *Table, maxRows, maxSize int) *batchInserter {
+	columnNamesForInsert := make([]string, len(table.Columns))
+	for i := range table.Columns {
+		columnNamesForInsert[i] = fmt.Sprintf("`%s`", table.Columns[i].Name)
+	}
+
+	if maxRows <= 0 {
+		maxRows = defaultBatchRows
+	}
+
+	if len(table.Columns) > 0 && maxRows*len(table.Columns) > maxPlaceholders {
+		maxRows = maxPlaceholders / len(table.Columns)
+	}
+
+	return &batchInserter{
+		db:        db.DB(),
+		table:     table,
+		tableName: db.TableNameForQuery(table.Name),
+		columns:   strings.Join(columnNamesForInsert, ","),
+		maxRows:   maxRows,
+		maxSize:   maxSize,
+	}
+}
+
+// Add buffers a copy of values, flushing the buffered rows first if adding
+// them would exceed the batch size. It returns the number of rows inserted
+// by any flush it triggered.
+func (b *batchInserter) Add(values []interface{}) (int64, error) {
+	row := make([]interface{}, len(values))
+	for i := range values {
+		row[i] = values[i]
+		if iface, ok := row[i].(*interface{}); ok {
+			row[i] = *iface
+		}
+	}
+	for _, i := range b.nullColumns {
+		row[i] = nil
+	}
+
+	rowSize := estimateRowSize(row)
+
+	var inserted int64
+	if len(b.rows) > 0 && b.maxSize > 0 && b.size+rowSize > b.maxSize {
+		var err error
+		inserted, err = b.Flush()
+		if err != nil {
+			return inserted, err
+		}
+	}
+
+	b.rows = append(b.rows, row)
+	b.size += rowSize
+
+	if len(b.rows) >= b.maxRows {
+		n, err := b.Flush()
+		return inserted + n, err
+	}
+
+	return inserted, nil
+}
+
+// Flush writes all buffered rows and returns how many were inserted. Rows
+// that cannot be inserted are reported on stderr and skipped.
+func (b *batchInserter) Flush() (int64, error) {
+	if len(b.rows) == 0 {
+		return 0, nil
+	}
+
+	inserted := b.insertOrBisect(b.rows)
+	lastRow := b.rows[len(b.rows)-1]
+
+	b.rows = nil
+	b.size = 0
+
+	if b.afterFlush != nil {
+		if err := b.afterFlush(lastRow); err != nil {
+			return inserted, err
+		}
+	}
+
+	return inserted, nil
+}
+
+func (b *batchInserter) insertOrBisect(rows [][]interface{}) int64 {
+	err := b.insert(rows)
+	if err == nil {
+		return int64(len(rows))
+	}
+
+	if len(rows) == 1 {
+		fmt.Fprintf(os.Stderr, "failed to insert into %s: %s\n", b.table.Name, err)
+		return 0
+	}
+
+	mid := len(rows) / 2
+	return b.insertOrBisect(rows[:mid]) + b.insertOrBisect(rows[mid:])
+}
+
+func (b *batchInserter) insert(rows [][]interface{}) error {
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(b.table.Columns)), ",") + ")"
+	placeholders := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(b.table.Columns))
+	for i := range rows {
+		placeholders[i] = placeholder
+		args = append(args, rows[i]...)
+	}
+
+	stmt := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		b.tableName,
+		b.columns,
+		strings.Join(placeholders, ","),
+	)
+
+	result, err := b.db.Exec(stmt, args...)
+	if err != nil {
+		return fmt.Errorf("failed to exec stmt: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed getting rows affected by insert: %s", err)
+	}
+
+	if rowsAffected != int64(len(rows)) {
+		return fmt.Errorf("expected %d rows to be affected by insert, got %d", len(rows), rowsAffected)
+	}
+
+	return nil
+}
+
+// estimateRowSize approximates the number of bytes a row occupies in an
+// execute packet.
+func estimateRowSize(row []interface{}) int {
+	size := 0
+	for _, v := range row {
+		switch v := v.(type) {
+		case nil:
+		case []byte:
+			size += len(v)
+		case string:
+			size += len(v)
+		case time.Time:
+			size += 12
+		default:
+			size += 8
+		}
+		size += 9 // length prefix and null-bitmap/type overhead
+	}
+	return size
+}
+
+// maxBatchSize returns the largest batch, in bytes, that fits in the
+// server's max_allowed_packet, capped at limit when limit is positive.
+func maxBatchSize(db *sql.DB, limit int) (int, error) {
+	var maxAllowedPacket int
+	err := db.QueryRow("SELECT @@max_allowed_packet").Scan(&maxAllowedPacket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read max_allowed_packet: %s", err)
+	}
+
+	size := maxAllowedPacket - packetHeadroom
+	if size <= 0 {
+		size = maxAllowedPacket
+	}
+
+	if limit > 0 && limit < size {
+		size = limit
+	}
+
+	return size, nil
+}
@@ -0,0 +1,312 @@
+package pg2mysql
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// keyReadTimeout is the net_write_timeout, in seconds, of the connection
+// streaming destination keys. The server waits on the client while the
+// source rows between two keys are read and inserted, which can take longer
+// than the default of a minute.
+const keyReadTimeout = 3600
+
+// stringKeyTypes are the source column types, besides integers, whose keys
+// sort the same in both databases when compared byte by byte.
+var stringKeyTypes = map[string]bool{
+	"character varying": true,
+	"character":         true,
+	"text":              true,
+	"uuid":              true,
+}
+
+// keyPart is a column of a table's key along with the expressions used to
+// order it in the source and destination so that both sort keys
+// identically. Integer columns sort naturally; any other type is compared
+// byte by byte on both sides.
+type keyPart struct {
+	index    int
+	name     string
+	srcOrder string
+	dstOrder string
+	numeric  bool
+}
+
+// keyParts returns the parts of the key of table, or false if the key
+// cannot be read in the same order from the source and the destination.
+func keyParts(table *Table) ([]keyPart, bool) {
+	indexes, columns, err := table.GetKeyColumns()
+	if err != nil {
+		return nil, false
+	}
+
+	parts := make([]keyPart, len(columns))
+	for i, column := range columns {
+		switch {
+		case integerTypes[column.Type]:
+			parts[i] = keyPart{
+				index:    indexes[i],
+				name:     column.Name,
+				srcOrder: column.Name,
+				dstOrder: column.Name,
+				numeric:  true,
+			}
+		case stringKeyTypes[column.Type]:
+			parts[i] = keyPart{
+				index:    indexes[i],
+				name:     column.Name,
+				srcOrder: fmt.Sprintf(`%s::text COLLATE "C"`, column.Name),
+				dstOrder: fmt.Sprintf("BINARY %s", column.Name),
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	return parts, true
+}
+
+// compareKeys compares two key tuples scanned from either database column
+// by column, returning -1, 0 or 1.
+func compareKeys(a, b []interface{}) int {
+	for i := range a {
+		if c := compareKeyValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// compareKeyValues compares two key values, numerically if both are
+// integers and as bytes otherwise.
+func compareKeyValues(a, b interface{}) int {
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	return bytes.Compare(keyBytes(a), keyBytes(b))
+}
+
+func keyBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+// keyCursor walks the keys of a table in the destination in ascending
+// order, so that they can be merged with the keys of the source rows
+// without holding every key in memory. The keys are streamed from a single
+// query, so that the destination sorts them once, over a connection of its
+// own, since the destination connection is used for inserts meanwhile.
+type keyCursor struct {
+	db    DB
+	table *Table
+	parts []keyPart
+	r     *keyRange
+
+	conn DB
+	rows *sql.Rows
+	key  []interface{}
+	done bool
+}
+
+func newKeyCursor(db DB, table *Table, parts []keyPart, r *keyRange) *keyCursor {
+	return &keyCursor{
+		db:    db,
+		table: table,
+		parts: parts,
+		r:     r,
+	}
+}
+
+// Contains reports whether key is among the destination keys. Keys must be
+// passed in ascending order.
+func (c *keyCursor) Contains(key []interface{}) (bool, error) {
+	if c.rows == nil && !c.done {
+		if err := c.open(); err != nil {
+			return false, err
+		}
+	}
+
+	for {
+		if c.key == nil {
+			if c.done {
+				return false, nil
+			}
+
+			if err := c.next(); err != nil {
+				return false, err
+			}
+
+			continue
+		}
+
+		switch compareKeys(c.key, key) {
+		case 0:
+			return true, nil
+		case 1:
+			return false, nil
+		}
+
+		c.key = nil
+	}
+}
+
+// Close closes the query and the connection of the cursor.
+func (c *keyCursor) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	if c.rows != nil {
+		c.rows.Close()
+	}
+
+	return c.conn.Close()
+}
+
+func (c *keyCursor) open() error {
+	names := make([]string, len(c.parts))
+	orders := make([]string, len(c.parts))
+	for i, part := range c.parts {
+		names[i] = c.db.ColumnNameForSelect(part.name)
+		orders[i] = part.dstOrder
+	}
+
+	stmt := fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ","), c.db.TableNameForQuery(c.table.Name))
+
+	// ranges are only planned for tables with a single integer key
+	where, args := c.r.Where(c.parts[0].name, mysqlPlaceholder)
+	if where != "" {
+		stmt = fmt.Sprintf("%s WHERE %s", stmt, where)
+	}
+	stmt = fmt.Sprintf("%s ORDER BY %s", stmt, strings.Join(orders, ","))
+
+	c.conn = c.db.Clone()
+	if err := c.conn.Open(); err != nil {
+		return fmt.Errorf("failed to open connection for keys: %s", err)
+	}
+	c.conn.DB().SetMaxOpenConns(1)
+
+	_, err := c.conn.Querier().Exec(fmt.Sprintf("SET SESSION net_write_timeout = %d", keyReadTimeout))
+	if err != nil {
+		return fmt.Errorf("failed to set net_write_timeout: %s", err)
+	}
+
+	c.rows, err = c.conn.Querier().Query(stmt, args...)
+	if err != nil {
+		return fmt.Errorf("failed to select keys: %s", err)
+	}
+
+	return nil
+}
+
+// next reads the next destination key into c.key, or sets c.done once
+// there are none left.
+func (c *keyCursor) next() error {
+	if !c.rows.Next() {
+		c.done = true
+		if err := c.rows.Err(); err != nil {
+			return fmt.Errorf("failed iterating through rows: %s", err)
+		}
+		return nil
+	}
+
+	// integer keys are scanned as such so that they compare numerically
+	// regardless of which protocol the driver used
+	key := make([]interface{}, len(c.parts))
+	numbers := make([]int64, len(c.parts))
+	scanArgs := make([]interface{}, len(c.parts))
+	for i, part := range c.parts {
+		if part.numeric {
+			scanArgs[i] = &numbers[i]
+		} else {
+			scanArgs[i] = &key[i]
+		}
+	}
+
+	if err := c.rows.Scan(scanArgs...); err != nil {
+		return fmt.Errorf("failed to scan key from row: %s", err)
+	}
+
+	for i, part := range c.parts {
+		if part.numeric {
+			key[i] = numbers[i]
+		}
+	}
+
+	c.key = key
+
+	return nil
+}
+
+// eachMissingKey calls f with the key of each row of table in src whose key
+// is missing from dst. The keys of both are walked in the same order, so
+// neither is held in memory.
+func eachMissingKey(src, dst DB, table *Table, parts []keyPart, f func(key []interface{})) error {
+	names := make([]string, len(parts))
+	orders := make([]string, len(parts))
+	for i, part := range parts {
+		names[i] = src.ColumnNameForSelect(part.name)
+		orders[i] = part.srcOrder
+	}
+
+	stmt := fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s",
+		strings.Join(names, ","),
+		src.TableNameForQuery(table.Name),
+		strings.Join(orders, ","),
+	)
+	rows, err := src.Querier().Query(stmt)
+	if err != nil {
+		return fmt.Errorf("failed to select keys: %s", err)
+	}
+	defer rows.Close()
+
+	dstKeys := newKeyCursor(dst, table, parts, nil)
+	defer dstKeys.Close()
+
+	key := make([]interface{}, len(parts))
+	scanArgs := make([]interface{}, len(parts))
+	for i := range key {
+		scanArgs[i] = &key[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to scan key from row: %s", err)
+		}
+
+		exists, err := dstKeys.Contains(key)
+		if err != nil {
+			return fmt.Errorf("failed to find key in dst: %s", err)
+		}
+
+		if !exists {
+			f(key)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed iterating through rows: %s", err)
+	}
+
+	return nil
+}
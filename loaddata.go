@@ -0,0 +1,307 @@
+package pg2mysql
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// localInfileEnabled reports whether the MySQL server accepts
+// LOAD DATA LOCAL INFILE.
+func localInfileEnabled(db DB) (bool, error) {
+	var enabled bool
+	err := db.Querier().QueryRow("SELECT @@local_infile").Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to read local_infile: %s", err)
+	}
+
+	return enabled, nil
+}
+
+// tableIsEmpty reports whether table has no rows in db.
+func tableIsEmpty(db DB, table *Table) (bool, error) {
+	var exists bool
+	err := db.Querier().QueryRow(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", db.TableNameForQuery(table.Name))).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is empty: %s", table.Name, err)
+	}
+
+	return !exists, nil
+}
+
+// loadDataWarnings is returned by loadTableData when the server warned
+// about the rows it loaded. LOAD DATA LOCAL INFILE behaves as if IGNORE
+// were given, so values that an INSERT would reject are truncated or
+// coerced instead, and rows with duplicate keys are skipped.
+type loadDataWarnings struct {
+	count    int64
+	messages []string
+
+	// skipped is the number of rows read that were not loaded.
+	skipped int64
+}
+
+func (w *loadDataWarnings) Error() string {
+	message := fmt.Sprintf("%d warnings", w.count)
+	if w.skipped > 0 {
+		message = fmt.Sprintf("%s and skipped %d rows", message, w.skipped)
+	}
+	if len(w.messages) > 0 {
+		message = fmt.Sprintf("%s: %s", message, strings.Join(w.messages, "; "))
+	}
+	return message
+}
+
+// maxLoadDataWarnings is the number of warnings reported by
+// loadDataWarnings.
+const maxLoadDataWarnings = 3
+
+// loadTableData copies every row of table within r from src into dst by
+// streaming them through LOAD DATA LOCAL INFILE, leaving the columns of
+// nullColumns NULL. It returns the number of rows loaded. The rows are
+// loaded in a transaction, which is rolled back and a *loadDataWarnings
+// returned if the server warned about any of them.
+func loadTableData(src, dst DB, table *Table, r *keyRange, infinity InfinityMapping, nullColumns []int) (int64, error) {
+	columnNamesForSelect := make([]string, len(table.Columns))
+	columnNamesForLoad := make([]string, len(table.Columns))
+	var assignments []string
+	for i := range table.Columns {
+		columnNamesForSelect[i] = src.ColumnNameForSelect(table.Columns[i].Name)
+		columnNamesForLoad[i] = dst.ColumnNameForSelect(table.Columns[i].Name)
+
+		// binary values are written hex-encoded so that they are not
+		// converted from the character set of the data
+		if table.Columns[i].Type == "bytea" {
+			variable := fmt.Sprintf("@c%d", i)
+			assignments = append(assignments, fmt.Sprintf("%s = UNHEX(%s)", columnNamesForLoad[i], variable))
+			columnNamesForLoad[i] = variable
+		}
+	}
+	for _, i := range nullColumns {
+		assignments = append(assignments, fmt.Sprintf("%s = NULL", dst.ColumnNameForSelect(table.Columns[i].Name)))
+		columnNamesForLoad[i] = fmt.Sprintf("@c%d", i)
+	}
+
+	stmt := fmt.Sprintf(
+		"SELECT %s FROM %s",
+		strings.Join(columnNamesForSelect, ","),
+		src.TableNameForQuery(table.Name),
+	)
+
+	// only tables with an integer key are split into ranges
+	var args []interface{}
+	if r != nil {
+		_, keyColumn, ok := integerKey(table)
+		if !ok {
+			return 0, fmt.Errorf("table %s has no integer key to split on", table.Name)
+		}
+
+		var where string
+		where, args = r.Where(keyColumn.Name, postgresPlaceholder(0))
+		stmt = fmt.Sprintf("%s WHERE %s", stmt, where)
+	}
+
+	rows, err := src.Querier().Query(stmt, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select rows: %s", err)
+	}
+	defer rows.Close()
+
+	// the warnings can only be read on the connection that loaded the rows
+	tx, err := dst.DB().Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	pr, pw := io.Pipe()
+	var rowsRead int64
+	done := make(chan struct{})
+	go func() {
+		pw.CloseWithError(writeLoadData(pw, rows, table, infinity, &rowsRead))
+		close(done)
+	}()
+
+	readerName := fmt.Sprintf("pg2mysql_%s_%p", table.Name, r)
+	mysql.RegisterReaderHandler(readerName, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(readerName)
+
+	load := fmt.Sprintf(
+		`LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (%s)`,
+		readerName,
+		dst.TableNameForQuery(table.Name),
+		strings.Join(columnNamesForLoad, ","),
+	)
+	if len(assignments) > 0 {
+		load = fmt.Sprintf("%s SET %s", load, strings.Join(assignments, ","))
+	}
+
+	result, err := tx.Exec(load)
+
+	// unblock the writer if the server stopped reading early
+	pr.Close()
+	<-done
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to load data: %s", err)
+	}
+
+	rowsLoaded, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed getting rows affected by load: %s", err)
+	}
+
+	warnings, err := readWarnings(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	warnings.skipped = rowsRead - rowsLoaded
+	if warnings.count > 0 || warnings.skipped > 0 {
+		return 0, warnings
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit load: %s", err)
+	}
+
+	return rowsLoaded, nil
+}
+
+// readWarnings returns the warnings raised by the last statement run in
+// tx, with the first maxLoadDataWarnings of their messages.
+func readWarnings(tx *sql.Tx) (*loadDataWarnings, error) {
+	warnings := &loadDataWarnings{}
+	err := tx.QueryRow("SELECT @@warning_count").Scan(&warnings.count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read warning count: %s", err)
+	}
+
+	if warnings.count == 0 {
+		return warnings, nil
+	}
+
+	rows, err := tx.Query(fmt.Sprintf("SHOW WARNINGS LIMIT %d", maxLoadDataWarnings))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read warnings: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var level, message string
+		var code int
+		if err := rows.Scan(&level, &code, &message); err != nil {
+			return nil, fmt.Errorf("failed to scan warning: %s", err)
+		}
+		warnings.messages = append(warnings.messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating through warnings: %s", err)
+	}
+
+	return warnings, nil
+}
+
+func writeLoadData(w io.Writer, rows *sql.Rows, table *Table, infinity InfinityMapping, rowsRead *int64) error {
+	values := make([]interface{}, len(table.Columns))
+	scanArgs := make([]interface{}, len(table.Columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	bw := bufio.NewWriter(w)
+	var line []byte
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to scan row: %s", err)
+		}
+
+		infinity.apply(table, values)
+
+		line = line[:0]
+		for i, v := range values {
+			if i > 0 {
+				line = append(line, '\t')
+			}
+			if b, ok := v.([]byte); ok && table.Columns[i].Type == "bytea" {
+				line = appendLoadDataHex(line, b)
+				continue
+			}
+			line = appendLoadDataValue(line, v)
+		}
+		line = append(line, '\n')
+
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+		*rowsRead++
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed iterating through rows: %s", err)
+	}
+
+	return bw.Flush()
+}
+
+// appendLoadDataValue appends v to buf encoded for a LOAD DATA statement
+// using tab-separated fields and backslash escaping.
+func appendLoadDataValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, `\N`...)
+	case bool:
+		if v {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case time.Time:
+		return v.In(time.UTC).AppendFormat(buf, "2006-01-02 15:04:05.999999")
+	case []byte:
+		return appendLoadDataEscaped(buf, v)
+	case string:
+		return appendLoadDataEscaped(buf, []byte(v))
+	default:
+		return appendLoadDataEscaped(buf, []byte(fmt.Sprint(v)))
+	}
+}
+
+// appendLoadDataHex appends v hex-encoded, to be decoded with UNHEX.
+func appendLoadDataHex(buf, v []byte) []byte {
+	const digits = "0123456789ABCDEF"
+	for _, c := range v {
+		buf = append(buf, digits[c>>4], digits[c&0xf])
+	}
+	return buf
+}
+
+func appendLoadDataEscaped(buf, v []byte) []byte {
+	for _, c := range v {
+		switch c {
+		case '\\':
+			buf = append(buf, '\\', '\\')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case 0:
+			buf = append(buf, '\\', '0')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -1,118 +1,731 @@
 package pg2mysql
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Migrator interface {
 	Migrate() error
 }
 
-func NewMigrator(src, dst DB, truncateFirst bool, watcher MigratorWatcher) Migrator {
-	return &migrator{
+// MigratorOption configures optional behaviour of a Migrator.
+type MigratorOption func(*migrator)
+
+// WithBatchSize sets the maximum number of rows and the maximum number of
+// bytes sent in a single INSERT statement. A zero value leaves the
+// corresponding limit at its default; the byte limit is always capped by the
+// destination's max_allowed_packet.
+func WithBatchSize(rows, bytes int) MigratorOption {
+	return func(m *migrator) {
+		m.batchRows = rows
+		m.batchBytes = bytes
+	}
+}
+
+// WithLoadData makes the migrator copy tables that are empty in the
+// destination using LOAD DATA LOCAL INFILE instead of INSERT statements. It
+// falls back to INSERT statements if the server has local_infile disabled.
+func WithLoadData(enabled bool) MigratorOption {
+	return func(m *migrator) {
+		m.loadData = enabled
+	}
+}
+
+// WithJobs sets the number of tables, or chunks of tables, migrated
+// concurrently.
+func WithJobs(jobs int) MigratorOption {
+	return func(m *migrator) {
+		m.jobs = jobs
+	}
+}
+
+// WithChunkSize splits tables with an integer key and more than rows rows
+// into ranges of keys holding roughly that many rows each, so that a
+// single large table can be copied by several jobs at once. A zero value
+// disables chunking.
+func WithChunkSize(rows int64) MigratorOption {
+	return func(m *migrator) {
+		m.chunkSize = rows
+	}
+}
+
+// WithCheckpoint records the progress of the migration in store. When resume
+// is true, the migration continues from the checkpoint previously saved in
+// store: completed tables are skipped and tables with an integer key
+// continue after the last key copied.
+func WithCheckpoint(store CheckpointStore, resume bool) MigratorOption {
+	return func(m *migrator) {
+		m.checkpointStore = store
+		m.resume = resume
+	}
+}
+
+// WithInfinityMapping stores the infinite dates and times of PostgreSQL as
+// the values given by mapping.
+func WithInfinityMapping(mapping InfinityMapping) MigratorOption {
+	return func(m *migrator) {
+		m.infinity = mapping
+	}
+}
+
+// WithSequenceTable copies the state of the sequences that are not owned by
+// a column into table in MySQL, which is created if it does not exist.
+func WithSequenceTable(table string) MigratorOption {
+	return func(m *migrator) {
+		m.sequenceTable = table
+	}
+}
+
+func NewMigrator(src, dst DB, truncateFirst bool, watcher MigratorWatcher, opts ...MigratorOption) Migrator {
+	m := &migrator{
 		src:           src,
 		dst:           dst,
 		truncateFirst: truncateFirst,
 		watcher:       watcher,
+		batchRows:     defaultBatchRows,
+		jobs:          1,
 	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.jobs > 1 {
+		m.watcher = &synchronizedMigratorWatcher{watcher: m.watcher}
+	}
+
+	return m
 }
 
 type migrator struct {
 	src, dst      DB
 	truncateFirst bool
 	watcher       MigratorWatcher
+	batchRows     int
+	batchBytes    int
+	loadData      bool
+	jobs          int
+	chunkSize     int64
+	infinity      InfinityMapping
+	sequenceTable string
+
+	// dstSchema is the schema of dst, read when the migration begins.
+	dstSchema *Schema
+
+	// selfReferences holds the columns of each table that references
+	// itself, which are inserted as NULL and updated once the table is
+	// complete.
+	selfReferences map[string][]int
+
+	checkpointStore CheckpointStore
+	resume          bool
+	checkpointer    *checkpointer
 }
 
 func (m *migrator) Migrate() error {
+	if m.resume && m.truncateFirst {
+		return errors.New("cannot truncate tables when resuming a migration")
+	}
+
+	// read every table as of the same instant; workers join the snapshot
+	// when they clone the source connection
+	err := m.src.BeginSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to begin snapshot: %s", err)
+	}
+	defer m.src.EndSnapshot()
+
 	srcSchema, err := BuildSchema(m.src)
 	if err != nil {
 		return fmt.Errorf("failed to build source schema: %s", err)
 	}
 
-	m.watcher.WillDisableConstraints()
-	err = m.dst.DisableConstraints()
+	m.checkpointer = nil
+	if m.checkpointStore != nil {
+		checkpoint := &Checkpoint{Tables: map[string]*TableCheckpoint{}}
+		if m.resume {
+			checkpoint, err = m.checkpointStore.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load checkpoint: %s", err)
+			}
+		}
+
+		m.checkpointer = &checkpointer{
+			store:      m.checkpointStore,
+			checkpoint: checkpoint,
+		}
+	}
+
+	batchBytes, err := maxBatchSize(m.dst.DB(), m.batchBytes)
 	if err != nil {
-		return fmt.Errorf("failed to disable constraints: %s", err)
+		return err
 	}
-	m.watcher.DidDisableConstraints()
 
-	defer func() {
-		m.watcher.WillEnableConstraints()
-		err = m.dst.EnableConstraints()
+	loadData := m.loadData
+	if loadData {
+		loadData, err = localInfileEnabled(m.dst)
 		if err != nil {
-			m.watcher.EnableConstraintsDidFailWithError(err)
-		} else {
+			return err
+		}
+
+		if !loadData {
+			fmt.Fprintln(os.Stderr, "local_infile is disabled on the server, falling back to INSERT statements")
+		}
+	}
+
+	// migrate parents before children so that foreign key checks can stay
+	// enabled, which is not possible when the tables form a cycle
+	foreignKeys, err := readMySQLForeignKeys(m.dst)
+	if err != nil {
+		return fmt.Errorf("failed to read destination foreign keys: %s", err)
+	}
+
+	order := orderTables(srcSchema, foreignKeys)
+
+	cyclic := map[string]bool{}
+	for _, cycle := range order.cycles {
+		for _, name := range cycle {
+			cyclic[name] = true
+		}
+	}
+
+	m.dstSchema, err = BuildSchema(m.dst)
+	if err != nil {
+		return fmt.Errorf("failed to build destination schema: %s", err)
+	}
+
+	// rows of tables that reference themselves are inserted without their
+	// references, which are set once the table is complete
+	m.selfReferences = map[string][]int{}
+	for _, name := range order.selfReferencing {
+		if cyclic[name] {
+			continue
+		}
+
+		columns, ok := selfReferencingColumns(srcSchema.Tables[name], m.dstSchema.Tables[name], foreignKeys[name])
+		if !ok {
+			cyclic[name] = true
+			order.cycles = append(order.cycles, []string{name})
+			continue
+		}
+		m.selfReferences[name] = columns
+	}
+
+	for _, cycle := range order.cycles {
+		m.watcher.DidFindForeignKeyCycle(cycle)
+	}
+
+	if m.truncateFirst {
+		if err = m.clearTables(order.levels, cyclic); err != nil {
+			return err
+		}
+	}
+
+	// each level of tables is finished before the next begins; the tables
+	// of a level that form cycles are migrated in a stage of their own with
+	// foreign key checks disabled
+	var stages []*migrationStage
+	for _, level := range order.levels {
+		checked := &migrationStage{}
+		unchecked := &migrationStage{constraintsDisabled: true}
+
+		for _, table := range level {
+			stage := checked
+			if cyclic[table.Name] {
+				stage = unchecked
+			}
+
+			tableTasks, err := m.planTable(table, loadData)
+			if err != nil {
+				return err
+			}
+			stage.tables = append(stage.tables, table)
+			stage.tasks = append(stage.tasks, tableTasks...)
+		}
+
+		for _, stage := range []*migrationStage{checked, unchecked} {
+			if len(stage.tables) > 0 {
+				stages = append(stages, stage)
+			}
+		}
+	}
+
+	for _, stage := range stages {
+		if err = m.runStage(stage, batchBytes); err != nil {
+			return err
+		}
+	}
+
+	err = resetAutoIncrements(m.src, m.dst, srcSchema, m.watcher)
+	if err != nil {
+		return err
+	}
+
+	if m.sequenceTable != "" {
+		return migrateSequences(m.src, m.dst, m.sequenceTable, m.watcher)
+	}
+
+	return nil
+}
+
+// clearTables empties every table, the tables of each level before those
+// they reference, so that foreign key checks can stay enabled. Tables are
+// truncated, except those referenced by a foreign key of another table,
+// which MySQL refuses to truncate while foreign key checks are enabled;
+// their rows are deleted instead. Tables that form cycles are truncated on
+// a connection with foreign key checks disabled.
+func (m *migrator) clearTables(levels [][]*Table, cyclic map[string]bool) error {
+	referenced, err := readReferencedTables(m.dst)
+	if err != nil {
+		return fmt.Errorf("failed to read referenced tables: %s", err)
+	}
+
+	var unchecked DB
+	defer func() {
+		if unchecked != nil {
+			m.watcher.WillEnableConstraints()
+			unchecked.Close()
 			m.watcher.EnableConstraintsDidFinish()
 		}
 	}()
 
-	for _, table := range srcSchema.Tables {
-		if m.truncateFirst {
+	for i := len(levels) - 1; i >= 0; i-- {
+		for _, table := range levels[i] {
+			db := m.dst
+			if cyclic[table.Name] {
+				if unchecked == nil {
+					m.watcher.WillDisableConstraints()
+					conn, err := m.openDestination(true)
+					if err != nil {
+						return err
+					}
+					unchecked = conn
+					m.watcher.DidDisableConstraints()
+				}
+				db = unchecked
+			}
+
 			m.watcher.WillTruncateTable(table.Name)
-			_, err := m.dst.DB().Exec(fmt.Sprintf("TRUNCATE TABLE %s", table.Name))
+
+			tableName := db.TableNameForQuery(table.Name)
+
+			if cyclic[table.Name] || !referenced[table.Name] {
+				_, err := db.Querier().Exec(fmt.Sprintf("TRUNCATE TABLE %s", tableName))
+				if err != nil {
+					return fmt.Errorf("failed truncating: %s", err)
+				}
+				m.watcher.TruncateTableDidFinish(table.Name)
+				continue
+			}
+
+			// rows may reference other rows of the same table
+			if columns, ok := m.selfReferences[table.Name]; ok {
+				assignments := make([]string, len(columns))
+				for j, index := range columns {
+					assignments[j] = fmt.Sprintf("%s = NULL", db.ColumnNameForSelect(table.Columns[index].Name))
+				}
+
+				_, err := db.Querier().Exec(fmt.Sprintf("UPDATE %s SET %s", tableName, strings.Join(assignments, ",")))
+				if err != nil {
+					return fmt.Errorf("failed truncating: %s", err)
+				}
+			}
+
+			_, err := db.Querier().Exec(fmt.Sprintf("DELETE FROM %s", tableName))
 			if err != nil {
 				return fmt.Errorf("failed truncating: %s", err)
 			}
 			m.watcher.TruncateTableDidFinish(table.Name)
 		}
+	}
+
+	return nil
+}
 
-		columnNamesForInsert := make([]string, len(table.Columns))
-		placeholders := make([]string, len(table.Columns))
-		for i := range table.Columns {
-			columnNamesForInsert[i] = fmt.Sprintf("`%s`", table.Columns[i].Name)
-			placeholders[i] = "?"
+// migrationStage is a group of tasks that may run concurrently, along
+// with the tables they copy.
+type migrationStage struct {
+	tables              []*Table
+	tasks               []*migrationTask
+	constraintsDisabled bool
+}
+
+// migrationTask is a unit of work for the migrator: either a whole table or
+// one range of ids within it.
+type migrationTask struct {
+	table    *tableProgress
+	keyRange *keyRange
+	chunk    int
+	loadData bool
+
+	checkpoint *ChunkCheckpoint
+}
+
+// tableProgress tracks the tasks of a table so that the watcher is told
+// when the first one starts and when the last one finishes.
+type tableProgress struct {
+	mu              sync.Mutex
+	table           *Table
+	checkpoint      *TableCheckpoint
+	chunks          int
+	started         bool
+	remaining       int
+	recordsInserted int64
+}
+
+func (m *migrator) planTable(table *Table, useLoadData bool) ([]*migrationTask, error) {
+	var tc *TableCheckpoint
+	if m.checkpointer != nil {
+		tc = m.checkpointer.table(table.Name)
+		if tc != nil && tc.Completed {
+			m.watcher.DidSkipCompletedTable(table.Name)
+			return nil, nil
 		}
+	}
 
-		preparedStmt, err := m.dst.DB().Prepare(fmt.Sprintf(
-			"INSERT INTO %s (%s) VALUES (%s)",
-			table.Name,
-			strings.Join(columnNamesForInsert, ","),
-			strings.Join(placeholders, ","),
-		))
+	var err error
+	var ranges []*keyRange
+	var chunkCheckpoints []*ChunkCheckpoint
+	if tc != nil {
+		for _, chunk := range tc.Chunks {
+			ranges = append(ranges, chunk.remaining())
+		}
+		chunkCheckpoints = tc.Chunks
+	} else {
+		ranges, err = planChunks(m.src, table, m.chunkSize)
 		if err != nil {
-			return fmt.Errorf("failed creating prepared statement: %s", err)
+			return nil, err
 		}
 
-		var recordsInserted int64
+		if m.checkpointer != nil {
+			tc, err = m.checkpointer.startTable(table.Name, ranges)
+			if err != nil {
+				return nil, fmt.Errorf("failed to save checkpoint: %s", err)
+			}
+			chunkCheckpoints = tc.Chunks
+		}
+	}
 
-		m.watcher.TableMigrationDidStart(table.Name)
+	if useLoadData {
+		useLoadData, err = tableIsEmpty(m.dst, table)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		if table.HasColumn("id") {
-			err = migrateWithIDs(m.watcher, m.src, m.dst, table, &recordsInserted, preparedStmt)
-			if err != nil {
-				return fmt.Errorf("failed migrating table with ids: %s", err)
+	progress := &tableProgress{
+		table:      table,
+		checkpoint: tc,
+		chunks:     len(ranges),
+	}
+
+	var tasks []*migrationTask
+	for i, r := range ranges {
+		task := &migrationTask{
+			table:    progress,
+			keyRange: r,
+			chunk:    i + 1,
+			loadData: useLoadData,
+		}
+
+		if chunkCheckpoints != nil {
+			if chunkCheckpoints[i].Completed {
+				continue
 			}
-		} else {
-			err = EachMissingRow(m.src, m.dst, table, func(scanArgs []interface{}) {
-				err = insert(preparedStmt, scanArgs)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "failed to insert into %s: %s\n", table.Name, err)
+			task.checkpoint = chunkCheckpoints[i]
+		}
+
+		tasks = append(tasks, task)
+	}
+	progress.remaining = len(tasks)
+
+	return tasks, nil
+}
+
+// runStage runs the tasks of stage and then sets the references of the
+// tables of stage that reference themselves. Stages with foreign key checks
+// disabled are run on connections of their own, since the setting only
+// applies to the connection it was made on.
+func (m *migrator) runStage(stage *migrationStage, batchBytes int) error {
+	switch {
+	case len(stage.tasks) == 0:
+	case !stage.constraintsDisabled && (m.jobs <= 1 || len(stage.tasks) <= 1):
+		for _, task := range stage.tasks {
+			if err := m.runTask(m.src, m.dst, task, batchBytes); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := m.runTasksInParallel(stage.tasks, batchBytes, stage.constraintsDisabled); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range stage.tables {
+		columns, ok := m.selfReferences[table.Name]
+		if !ok {
+			continue
+		}
+
+		if err := updateSelfReferences(m.src, m.dst, table, columns); err != nil {
+			return fmt.Errorf("failed to update references of %s: %s", table.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// runTasksInParallel runs tasks using a pool of workers, each with its own
+// source and destination connection. It stops handing out tasks after the
+// first failure and returns that failure once in-flight tasks have finished.
+// When constraintsDisabled is set, foreign key checks are disabled on the
+// destination connections of the workers.
+func (m *migrator) runTasksInParallel(tasks []*migrationTask, batchBytes int, constraintsDisabled bool) error {
+	jobs := m.jobs
+	if jobs > len(tasks) {
+		jobs = len(tasks)
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	type worker struct {
+		src, dst DB
+	}
+
+	if constraintsDisabled {
+		m.watcher.WillDisableConstraints()
+	}
+
+	var workers []worker
+	defer func() {
+		if constraintsDisabled {
+			m.watcher.WillEnableConstraints()
+		}
+
+		for _, w := range workers {
+			w.src.Close()
+			w.dst.Close()
+		}
+
+		// the setting ends with the connections it was made on
+		if constraintsDisabled {
+			m.watcher.EnableConstraintsDidFinish()
+		}
+	}()
+
+	for i := 0; i < jobs; i++ {
+		src, dst, err := m.openWorkerDBs(constraintsDisabled)
+		if err != nil {
+			return err
+		}
+		workers = append(workers, worker{src: src, dst: dst})
+	}
+
+	if constraintsDisabled {
+		m.watcher.DidDisableConstraints()
+	}
+
+	taskCh := make(chan *migrationTask)
+	errCh := make(chan error, jobs)
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(src, dst DB) {
+			defer wg.Done()
+			for task := range taskCh {
+				if err := m.runTask(src, dst, task, batchBytes); err != nil {
+					errCh <- err
 					return
 				}
-				recordsInserted++
-			})
-			if err != nil {
-				return fmt.Errorf("failed migrating table without ids: %s", err)
 			}
+		}(w.src, w.dst)
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var err error
+feed:
+	for _, task := range tasks {
+		select {
+		case taskCh <- task:
+		case err = <-errCh:
+			break feed
+		case <-done:
+			break feed
+		}
+	}
+	close(taskCh)
+	<-done
+
+	if err == nil {
+		select {
+		case err = <-errCh:
+		default:
+		}
+	}
+
+	return err
+}
+
+// openWorkerDBs opens a dedicated connection to each database for a worker,
+// disabling constraints on the destination connection if
+// constraintsDisabled is set.
+func (m *migrator) openWorkerDBs(constraintsDisabled bool) (DB, DB, error) {
+	src := m.src.Clone()
+	if err := src.Open(); err != nil {
+		return nil, nil, fmt.Errorf("failed to open source connection: %s", err)
+	}
+
+	dst, err := m.openDestination(constraintsDisabled)
+	if err != nil {
+		src.Close()
+		return nil, nil, err
+	}
+
+	return src, dst, nil
+}
+
+// openDestination opens a dedicated connection to the destination, since
+// session settings such as foreign key checks do not carry over to other
+// connections of a pool.
+func (m *migrator) openDestination(constraintsDisabled bool) (DB, error) {
+	dst := m.dst.Clone()
+	if err := dst.Open(); err != nil {
+		return nil, fmt.Errorf("failed to open destination connection: %s", err)
+	}
+	dst.DB().SetMaxOpenConns(1)
+
+	if constraintsDisabled {
+		if err := dst.DisableConstraints(); err != nil {
+			dst.Close()
+			return nil, fmt.Errorf("failed to disable constraints: %s", err)
+		}
+	}
+
+	return dst, nil
+}
+
+func (m *migrator) runTask(src, dst DB, task *migrationTask, batchBytes int) error {
+	progress := task.table
+	table := progress.table
+
+	progress.mu.Lock()
+	if !progress.started {
+		progress.started = true
+		m.watcher.TableMigrationDidStart(table.Name)
+	}
+	progress.mu.Unlock()
+
+	recordsInserted, err := m.copyTable(src, dst, table, task.keyRange, task.checkpoint, batchBytes, task.loadData)
+	if err != nil {
+		return err
+	}
+
+	if task.checkpoint != nil {
+		err = m.checkpointer.completeChunk(progress.checkpoint, task.checkpoint)
+		if err != nil {
+			return fmt.Errorf("failed to save checkpoint: %s", err)
 		}
+	}
+
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	progress.recordsInserted += recordsInserted
+	progress.remaining--
 
-		m.watcher.TableMigrationDidFinish(table.Name, recordsInserted)
+	if progress.chunks > 1 {
+		m.watcher.TableChunkMigrationDidFinish(table.Name, task.chunk, progress.chunks, recordsInserted)
+	}
+
+	if progress.remaining == 0 {
+		m.watcher.TableMigrationDidFinish(table.Name, progress.recordsInserted)
 	}
 
 	return nil
 }
 
-func migrateWithIDs(
-	watcher MigratorWatcher,
+func (m *migrator) copyTable(src, dst DB, table *Table, r *keyRange, checkpoint *ChunkCheckpoint, batchBytes int, useLoadData bool) (int64, error) {
+	// rows that LOAD DATA would only store with warnings are copied again
+	// with INSERT statements, which reject them and report each one
+	if useLoadData {
+		rowsLoaded, err := loadTableData(src, dst, table, r, m.infinity, m.selfReferences[table.Name])
+		if warnings, ok := err.(*loadDataWarnings); ok {
+			fmt.Fprintf(os.Stderr, "LOAD DATA into %s raised %s, falling back to INSERT statements\n", table.Name, warnings)
+		} else if err != nil {
+			return 0, fmt.Errorf("failed loading data: %s", err)
+		} else {
+			return rowsLoaded, nil
+		}
+	}
+
+	inserter := newBatchInserter(dst, table, m.batchRows, batchBytes)
+	inserter.nullColumns = m.selfReferences[table.Name]
+
+	// record the last key copied so that an interrupted migration can resume
+	// after it; rows are copied in key order
+	if keyIndex, _, ok := integerKey(table); ok && checkpoint != nil {
+		inserter.afterFlush = func(lastRow []interface{}) error {
+			key, ok := lastRow[keyIndex].(int64)
+			if !ok {
+				return fmt.Errorf("unexpected type for key: %T", lastRow[keyIndex])
+			}
+			return m.checkpointer.copiedThrough(checkpoint, key)
+		}
+	}
+
+	var recordsInserted int64
+
+	if parts, ok := keyParts(table); ok {
+		err := migrateWithKey(src, dst, table, parts, r, m.infinity, &recordsInserted, inserter)
+		if err != nil {
+			return 0, fmt.Errorf("failed migrating table with key: %s", err)
+		}
+	} else {
+		var insertErr error
+		err := eachMissingRow(src, dst, table, m.dstSchema.Tables[table.Name], m.infinity, func(scanArgs []interface{}) {
+			if insertErr != nil {
+				return
+			}
+			var n int64
+			n, insertErr = inserter.Add(scanArgs)
+			recordsInserted += n
+		})
+		if err == nil {
+			err = insertErr
+		}
+		if err == nil {
+			var n int64
+			n, err = inserter.Flush()
+			recordsInserted += n
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed migrating table without key: %s", err)
+		}
+	}
+
+	return recordsInserted, nil
+}
+
+func migrateWithKey(
 	src DB,
 	dst DB,
 	table *Table,
+	parts []keyPart,
+	r *keyRange,
+	infinity InfinityMapping,
 	recordsInserted *int64,
-	preparedStmt *sql.Stmt,
+	inserter *batchInserter,
 ) error {
 	columnNamesForSelect := make([]string, len(table.Columns))
 	values := make([]interface{}, len(table.Columns))
@@ -122,46 +735,30 @@ func migrateWithIDs(
 		scanArgs[i] = &values[i]
 	}
 
-	// find ids already in dst
-	rows, err := dst.DB().Query(fmt.Sprintf("SELECT id FROM %s", table.Name))
-	if err != nil {
-		return fmt.Errorf("failed to select id from rows: %s", err)
+	// walk the src rows and the keys already in dst in the same order,
+	// skipping rows whose key is present in both
+	srcOrders := make([]string, len(parts))
+	for i, part := range parts {
+		srcOrders[i] = part.srcOrder
 	}
+	key := make([]interface{}, len(parts))
+	dstKeys := newKeyCursor(dst, table, parts, r)
+	defer dstKeys.Close()
 
-	var dstIDs []interface{}
-	for rows.Next() {
-		var id interface{}
-		if err = rows.Scan(&id); err != nil {
-			return fmt.Errorf("failed to scan id from row: %s", err)
-		}
-		dstIDs = append(dstIDs, id)
-	}
-
-	if err = rows.Err(); err != nil {
-		return fmt.Errorf("failed iterating through rows: %s", err)
-	}
-
-	if err = rows.Close(); err != nil {
-		return fmt.Errorf("failed closing rows: %s", err)
-	}
-
-	// select data for ids to migrate from src
 	stmt := fmt.Sprintf(
 		"SELECT %s FROM %s",
 		strings.Join(columnNamesForSelect, ","),
-		table.Name,
+		src.TableNameForQuery(table.Name),
 	)
 
-	if len(dstIDs) > 0 {
-		placeholders := make([]string, len(dstIDs))
-		for i := range dstIDs {
-			placeholders[i] = fmt.Sprintf("$%d", i+1)
-		}
-
-		stmt = fmt.Sprintf("%s WHERE id NOT IN (%s)", stmt, strings.Join(placeholders, ","))
+	srcWhere, srcArgs := r.Where(parts[0].name, postgresPlaceholder(0))
+	if srcWhere != "" {
+		stmt = fmt.Sprintf("%s WHERE %s", stmt, srcWhere)
 	}
 
-	rows, err = src.DB().Query(stmt, dstIDs...)
+	stmt = fmt.Sprintf("%s ORDER BY %s", stmt, strings.Join(srcOrders, ","))
+
+	rows, err := src.Querier().Query(stmt, srcArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to select rows: %s", err)
 	}
@@ -171,13 +768,27 @@ func migrateWithIDs(
 			return fmt.Errorf("failed to scan row: %s", err)
 		}
 
-		err = insert(preparedStmt, scanArgs)
+		infinity.apply(table, values)
+
+		for i, part := range parts {
+			key[i] = values[part.index]
+		}
+
+		exists, err := dstKeys.Contains(key)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to insert into %s: %s\n", table.Name, err)
+			return fmt.Errorf("failed to find key in dst: %s", err)
+		}
+
+		if exists {
 			continue
 		}
 
-		*recordsInserted++
+		n, err := inserter.Add(scanArgs)
+		if err != nil {
+			return fmt.Errorf("failed to insert rows: %s", err)
+		}
+
+		*recordsInserted += n
 	}
 
 	if err = rows.Err(); err != nil {
@@ -188,23 +799,12 @@ func migrateWithIDs(
 		return fmt.Errorf("failed closing rows: %s", err)
 	}
 
-	return nil
-}
-
-func insert(stmt *sql.Stmt, values []interface{}) error {
-	result, err := stmt.Exec(values...)
-	if err != nil {
-		return fmt.Errorf("failed to exec stmt: %s", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
+	n, err := inserter.Flush()
 	if err != nil {
-		return fmt.Errorf("failed getting rows affected by insert: %s", err)
+		return fmt.Errorf("failed to insert rows: %s", err)
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("no rows affected by insert")
-	}
+	*recordsInserted += n
 
 	return nil
 }
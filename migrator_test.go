@@ -2,6 +2,9 @@ package pg2mysql_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -26,6 +29,10 @@ var _ = Describe("Migrator", func() {
 			"",
 			"127.0.0.1",
 			3306,
+			"",
+			"",
+			nil,
+			pg2mysql.TableFilter{},
 		)
 
 		err := mysql.Open()
@@ -38,6 +45,8 @@ var _ = Describe("Migrator", func() {
 			"127.0.0.1",
 			5432,
 			"disable",
+			nil,
+			pg2mysql.TableFilter{},
 		)
 		err = pg.Open()
 		Expect(err).NotTo(HaveOccurred())
@@ -153,6 +162,337 @@ var _ = Describe("Migrator", func() {
 			})
 		})
 
+		Context("when a table has an id column but no primary key or unique index", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE table_with_unkeyed_id (id integer, name text);
+				INSERT INTO table_with_unkeyed_id VALUES (1, 'some-name'), (1, 'some-name'), (2, 'other-name')`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_unkeyed_id (id int, name varchar(255))")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("INSERT INTO table_with_unkeyed_id VALUES (1, 'some-name'), (2, 'changed-name')")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_unkeyed_id")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_unkeyed_id")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("compares entire rows rather than ids, inserting each row that is missing", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					if tableName == "table_with_unkeyed_id" {
+						Expect(recordsInserted).To(Equal(int64(2)))
+					}
+				}
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_with_unkeyed_id WHERE id = 1").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(2)))
+
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_with_unkeyed_id WHERE id = 2").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(2)))
+			})
+		})
+
+		Context("when there are more rows in postgres than fit in a single batch", func() {
+			BeforeEach(func() {
+				for i := 1; i <= 5; i++ {
+					name := fmt.Sprintf("name-%d", i)
+					if i == 3 {
+						name = strings.Repeat("x", 256)
+					}
+
+					result, err := pgRunner.DB().Exec("INSERT INTO table_with_id (id, name, ci_name, created_at, truthiness) VALUES ($1, $2, 'ci-name', now(), true)", i, name)
+					Expect(err).NotTo(HaveOccurred())
+					rowsAffected, err := result.RowsAffected()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rowsAffected).To(BeNumerically("==", 1))
+				}
+
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithBatchSize(2, 0))
+			})
+
+			It("inserts every row except the one that does not fit", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var ids []int
+				rows, err := mysqlRunner.DB().Query("SELECT id FROM table_with_id ORDER BY id")
+				Expect(err).NotTo(HaveOccurred())
+				for rows.Next() {
+					var id int
+					Expect(rows.Scan(&id)).To(Succeed())
+					ids = append(ids, id)
+				}
+				Expect(rows.Err()).NotTo(HaveOccurred())
+				Expect(ids).To(Equal([]int{1, 2, 4, 5}))
+
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					if tableName == "table_with_id" {
+						Expect(recordsInserted).To(BeNumerically("==", 4))
+					}
+				}
+			})
+		})
+
+		Context("when loading data with LOAD DATA LOCAL INFILE", func() {
+			var currentTime time.Time
+
+			BeforeEach(func() {
+				currentTime = time.Now().UTC()
+
+				stmt := "INSERT INTO table_with_id (id, name, null_name, ci_name, created_at, truthiness) VALUES ($1, $2, $3, $4, $5, $6)"
+				_, err := pgRunner.DB().Exec(stmt, 1, "tab\there ü", nil, `back\slash`, currentTime, true)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = pgRunner.DB().Exec(stmt, 2, "new\nline", "\\N", "ci-name", currentTime, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithLoadData(true))
+			})
+
+			It("copies the values exactly", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				type row struct {
+					name       string
+					nullName   *string
+					ciName     string
+					createdAt  time.Time
+					truthiness bool
+				}
+
+				var rows []row
+				result, err := mysqlRunner.DB().Query("SELECT name, null_name, ci_name, created_at, truthiness FROM table_with_id ORDER BY id")
+				Expect(err).NotTo(HaveOccurred())
+				for result.Next() {
+					var r row
+					Expect(result.Scan(&r.name, &r.nullName, &r.ciName, &r.createdAt, &r.truthiness)).To(Succeed())
+					rows = append(rows, r)
+				}
+				Expect(result.Err()).NotTo(HaveOccurred())
+				Expect(rows).To(HaveLen(2))
+
+				Expect(rows[0].name).To(Equal("tab\there ü"))
+				Expect(rows[0].nullName).To(BeNil())
+				Expect(rows[0].ciName).To(Equal(`back\slash`))
+				Expect(rows[0].createdAt.Format(time.RFC1123Z)).To(Equal(currentTime.Format(time.RFC1123Z)))
+				Expect(rows[0].truthiness).To(BeTrue())
+
+				Expect(rows[1].name).To(Equal("new\nline"))
+				Expect(rows[1].nullName).NotTo(BeNil())
+				Expect(*rows[1].nullName).To(Equal("\\N"))
+				Expect(rows[1].truthiness).To(BeFalse())
+			})
+
+			Context("when a value would only be loaded with a warning", func() {
+				BeforeEach(func() {
+					_, err := pgRunner.DB().Exec("INSERT INTO table_with_id (id, name, ci_name, created_at, truthiness) VALUES (3, $1, 'ci-name', now(), true)", strings.Repeat("x", 256))
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("copies the rows with INSERT statements instead, rejecting the row that does not fit", func() {
+					err := migrator.Migrate()
+					Expect(err).NotTo(HaveOccurred())
+
+					var ids []int
+					rows, err := mysqlRunner.DB().Query("SELECT id FROM table_with_id ORDER BY id")
+					Expect(err).NotTo(HaveOccurred())
+					for rows.Next() {
+						var id int
+						Expect(rows.Scan(&id)).To(Succeed())
+						ids = append(ids, id)
+					}
+					Expect(rows.Err()).NotTo(HaveOccurred())
+					Expect(ids).To(Equal([]int{1, 2}))
+				})
+			})
+		})
+
+		Context("when migrating tables in parallel", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("INSERT INTO table_with_id (id, name, ci_name, created_at, truthiness) VALUES (1, 'name', 'ci-name', now(), true)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_string_id (id, name) VALUES ('77add94c-be06-47db-9420-b4fd840396cd', 'name')")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = pgRunner.DB().Exec("INSERT INTO table_without_id (name, ci_name, created_at, truthiness) VALUES ('name', 'ci-name', now(), true)")
+				Expect(err).NotTo(HaveOccurred())
+
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithJobs(3))
+			})
+
+			It("migrates every table and notifies the watcher", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(watcher.TableMigrationDidStartCallCount()).To(Equal(3))
+				Expect(watcher.TableMigrationDidFinishCallCount()).To(Equal(3))
+
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					Expect(recordsInserted).To(BeNumerically("==", 1), fmt.Sprintf("unexpected result for %s", tableName))
+				}
+			})
+		})
+
+		Context("when a table is split into chunks", func() {
+			BeforeEach(func() {
+				for i := 1; i <= 10; i++ {
+					_, err := pgRunner.DB().Exec("INSERT INTO table_with_id (id, name, ci_name, created_at, truthiness) VALUES ($1, 'name', 'ci-name', now(), true)", i)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				_, err := pgRunner.DB().Exec("ANALYZE table_with_id")
+				Expect(err).NotTo(HaveOccurred())
+
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithJobs(2), pg2mysql.WithChunkSize(3))
+			})
+
+			It("migrates every chunk and notifies the watcher as each one finishes", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_with_id").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeNumerically("==", 10))
+
+				Expect(watcher.TableChunkMigrationDidFinishCallCount()).To(Equal(4))
+
+				var inserted int64
+				for i := 0; i < watcher.TableChunkMigrationDidFinishCallCount(); i++ {
+					tableName, _, chunks, recordsInserted := watcher.TableChunkMigrationDidFinishArgsForCall(i)
+					Expect(tableName).To(Equal("table_with_id"))
+					Expect(chunks).To(Equal(4))
+					inserted += recordsInserted
+				}
+				Expect(inserted).To(BeNumerically("==", 10))
+
+				Expect(watcher.TableMigrationDidStartCallCount()).To(Equal(3))
+				Expect(watcher.TableMigrationDidFinishCallCount()).To(Equal(3))
+			})
+
+			Context("when the table was never analyzed", func() {
+				BeforeEach(func() {
+					_, err := pgRunner.DB().Exec(`
+					CREATE TABLE chunk_unanalyzed (id integer PRIMARY KEY);
+					INSERT INTO chunk_unanalyzed SELECT generate_series(1, 10)`)
+					Expect(err).NotTo(HaveOccurred())
+					_, err = mysqlRunner.DB().Exec("CREATE TABLE chunk_unanalyzed (id int PRIMARY KEY)")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					_, err := pgRunner.DB().Exec("DROP TABLE chunk_unanalyzed")
+					Expect(err).NotTo(HaveOccurred())
+					_, err = mysqlRunner.DB().Exec("DROP TABLE chunk_unanalyzed")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("counts its rows to split it into chunks", func() {
+					err := migrator.Migrate()
+					Expect(err).NotTo(HaveOccurred())
+
+					var chunked int
+					for i := 0; i < watcher.TableChunkMigrationDidFinishCallCount(); i++ {
+						tableName, _, chunks, _ := watcher.TableChunkMigrationDidFinishArgsForCall(i)
+						if tableName == "chunk_unanalyzed" {
+							Expect(chunks).To(Equal(4))
+							chunked++
+						}
+					}
+					Expect(chunked).To(Equal(4))
+
+					var count int64
+					err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM chunk_unanalyzed").Scan(&count)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(BeNumerically("==", 10))
+				})
+			})
+		})
+
+		Context("when a checkpoint is used", func() {
+			var checkpointPath string
+
+			BeforeEach(func() {
+				for i := 1; i <= 4; i++ {
+					_, err := pgRunner.DB().Exec("INSERT INTO table_with_id (id, name, ci_name, created_at, truthiness) VALUES ($1, 'name', 'ci-name', now(), true)", i)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				checkpointFile, err := ioutil.TempFile("", "pg2mysql-checkpoint")
+				Expect(err).NotTo(HaveOccurred())
+				checkpointPath = checkpointFile.Name()
+				Expect(checkpointFile.Close()).To(Succeed())
+				Expect(os.Remove(checkpointPath)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				os.Remove(checkpointPath)
+			})
+
+			It("skips tables that were completed when resuming", func() {
+				store := pg2mysql.NewFileCheckpointStore(checkpointPath)
+				err := pg2mysql.NewMigrator(pg, mysql, false, watcher, pg2mysql.WithCheckpoint(store, false)).Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				checkpoint, err := store.Load()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkpoint.Tables).To(HaveLen(3))
+				for _, table := range checkpoint.Tables {
+					Expect(table.Completed).To(BeTrue())
+				}
+
+				resumeWatcher := &pg2mysqlfakes.FakeMigratorWatcher{}
+				err = pg2mysql.NewMigrator(pg, mysql, false, resumeWatcher, pg2mysql.WithCheckpoint(store, true)).Migrate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resumeWatcher.DidSkipCompletedTableCallCount()).To(Equal(3))
+				Expect(resumeWatcher.TableMigrationDidStartCallCount()).To(BeZero())
+			})
+
+			It("continues after the last id copied when resuming", func() {
+				lastKey := int64(2)
+				store := pg2mysql.NewFileCheckpointStore(checkpointPath)
+				err := store.Save(&pg2mysql.Checkpoint{
+					Tables: map[string]*pg2mysql.TableCheckpoint{
+						"table_with_id": {
+							Chunks: []*pg2mysql.ChunkCheckpoint{{LastKey: &lastKey}},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = pg2mysql.NewMigrator(pg, mysql, false, watcher, pg2mysql.WithCheckpoint(store, true)).Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var ids []int
+				rows, err := mysqlRunner.DB().Query("SELECT id FROM table_with_id ORDER BY id")
+				Expect(err).NotTo(HaveOccurred())
+				for rows.Next() {
+					var id int
+					Expect(rows.Scan(&id)).To(Succeed())
+					ids = append(ids, id)
+				}
+				Expect(rows.Err()).NotTo(HaveOccurred())
+				Expect(ids).To(Equal([]int{3, 4}))
+			})
+
+			It("refuses to truncate tables when resuming", func() {
+				store := pg2mysql.NewFileCheckpointStore(checkpointPath)
+				err := pg2mysql.NewMigrator(pg, mysql, true, watcher, pg2mysql.WithCheckpoint(store, true)).Migrate()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
 		Context("when there is compatible data in postgres in a table with a string 'id' column", func() {
 			BeforeEach(func() {
 				stmt := `
@@ -207,6 +547,617 @@ var _ = Describe("Migrator", func() {
 			})
 		})
 
+		Context("when some of the rows in postgres already exist in the target", func() {
+			BeforeEach(func() {
+				for _, id := range []string{"B", "a", "C", "d"} {
+					_, err := pgRunner.DB().Exec("INSERT INTO table_with_string_id (id, name) VALUES ($1, 'some-name')", id)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				for _, id := range []string{"a", "C"} {
+					_, err := mysqlRunner.DB().Exec("INSERT INTO table_with_string_id (id, name) VALUES (?, 'some-name')", id)
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("inserts only the missing rows", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					if tableName == "table_with_string_id" {
+						Expect(recordsInserted).To(Equal(int64(2)))
+					}
+				}
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_with_string_id").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(4)))
+			})
+		})
+
+		Context("when a table has a composite key", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_composite_key (space_id integer NOT NULL, user_guid varchar(36) NOT NULL, PRIMARY KEY (space_id, user_guid))")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_composite_key (space_id integer NOT NULL, user_guid varchar(36) NOT NULL, PRIMARY KEY (space_id, user_guid))")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_composite_key VALUES (1, 'a'), (1, 'b'), (2, 'a')")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("INSERT INTO table_with_composite_key VALUES (1, 'b')")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_composite_key")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_composite_key")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("inserts only the rows whose key is missing", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					if tableName == "table_with_composite_key" {
+						Expect(recordsInserted).To(Equal(int64(2)))
+					}
+				}
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_with_composite_key").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(3)))
+			})
+		})
+
+		Context("when there are characters outside the Basic Multilingual Plane", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_emoji (id integer PRIMARY KEY, comment text)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_emoji (id integer PRIMARY KEY, comment varchar(255) CHARACTER SET utf8mb4)")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_emoji VALUES (1, 'smile 😀')")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_emoji")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_emoji")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("copies them intact over a utf8mb4 connection", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var comment string
+				err = mysqlRunner.DB().QueryRow("SELECT CAST(comment AS BINARY) FROM table_with_emoji WHERE id = 1").Scan(&comment)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(comment).To(Equal("smile 😀"))
+			})
+		})
+
+		Context("when tables reference each other through foreign keys in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE dep_parents (id integer PRIMARY KEY);
+				CREATE TABLE dep_children (id integer PRIMARY KEY, parent_id integer);
+				INSERT INTO dep_parents VALUES (1), (2);
+				INSERT INTO dep_children VALUES (1, 1), (2, 2), (3, 2)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE dep_parents (id integer PRIMARY KEY)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(`CREATE TABLE dep_children (
+					id integer PRIMARY KEY,
+					parent_id integer,
+					FOREIGN KEY (parent_id) REFERENCES dep_parents (id)
+				)`)
+				Expect(err).NotTo(HaveOccurred())
+
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithJobs(2))
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE dep_children; DROP TABLE dep_parents")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE dep_children")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE dep_parents")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("migrates parents before children without disabling foreign key checks", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(watcher.WillDisableConstraintsCallCount()).To(BeZero())
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM dep_children").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeNumerically("==", 3))
+			})
+
+			Context("when truncating first", func() {
+				BeforeEach(func() {
+					_, err := mysqlRunner.DB().Exec("INSERT INTO dep_parents VALUES (1), (5)")
+					Expect(err).NotTo(HaveOccurred())
+					_, err = mysqlRunner.DB().Exec("INSERT INTO dep_children VALUES (9, 5)")
+					Expect(err).NotTo(HaveOccurred())
+
+					migrator = pg2mysql.NewMigrator(pg, mysql, true, watcher)
+				})
+
+				It("truncates the children and deletes the rows of the referenced parents", func() {
+					err := migrator.Migrate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(watcher.WillDisableConstraintsCallCount()).To(BeZero())
+
+					var ids []int
+					rows, err := mysqlRunner.DB().Query("SELECT id FROM dep_parents ORDER BY id")
+					Expect(err).NotTo(HaveOccurred())
+					for rows.Next() {
+						var id int
+						Expect(rows.Scan(&id)).To(Succeed())
+						ids = append(ids, id)
+					}
+					Expect(rows.Err()).NotTo(HaveOccurred())
+					Expect(ids).To(Equal([]int{1, 2}))
+
+					var count int64
+					err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM dep_children").Scan(&count)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(BeNumerically("==", 3))
+				})
+			})
+		})
+
+		Context("when a table references itself through a foreign key in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE dep_employees (id integer PRIMARY KEY, manager_id integer);
+				INSERT INTO dep_employees VALUES (1, 2), (2, NULL), (3, 3)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(`CREATE TABLE dep_employees (
+					id integer PRIMARY KEY,
+					manager_id integer,
+					FOREIGN KEY (manager_id) REFERENCES dep_employees (id)
+				)`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE dep_employees")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE dep_employees")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("sets the references after the rows without disabling foreign key checks", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(watcher.WillDisableConstraintsCallCount()).To(BeZero())
+				Expect(watcher.DidFindForeignKeyCycleCallCount()).To(BeZero())
+
+				var managers []*int64
+				rows, err := mysqlRunner.DB().Query("SELECT manager_id FROM dep_employees ORDER BY id")
+				Expect(err).NotTo(HaveOccurred())
+				for rows.Next() {
+					var manager *int64
+					Expect(rows.Scan(&manager)).To(Succeed())
+					managers = append(managers, manager)
+				}
+				Expect(rows.Err()).NotTo(HaveOccurred())
+
+				Expect(managers).To(HaveLen(3))
+				Expect(*managers[0]).To(BeNumerically("==", 2))
+				Expect(managers[1]).To(BeNil())
+				Expect(*managers[2]).To(BeNumerically("==", 3))
+			})
+
+			It("sets the references of more rows than fit in a single update", func() {
+				_, err := pgRunner.DB().Exec("INSERT INTO dep_employees SELECT n, n - 1 FROM generate_series(4, 2500) n")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM dep_employees WHERE id >= 4 AND manager_id = id - 1").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeNumerically("==", 2497))
+			})
+
+			Context("when truncating first", func() {
+				BeforeEach(func() {
+					_, err := mysqlRunner.DB().Exec("INSERT INTO dep_employees VALUES (10, NULL), (11, 10)")
+					Expect(err).NotTo(HaveOccurred())
+
+					migrator = pg2mysql.NewMigrator(pg, mysql, true, watcher)
+				})
+
+				It("deletes the existing rows without disabling foreign key checks", func() {
+					err := migrator.Migrate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(watcher.WillDisableConstraintsCallCount()).To(BeZero())
+
+					var count int64
+					err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM dep_employees").Scan(&count)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(BeNumerically("==", 3))
+				})
+			})
+		})
+
+		Context("when tables reference each other in a cycle in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE dep_authors (id integer PRIMARY KEY, latest_book_id integer);
+				CREATE TABLE dep_books (id integer PRIMARY KEY, author_id integer);
+				INSERT INTO dep_authors VALUES (1, 2);
+				INSERT INTO dep_books VALUES (2, 1)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(`
+				CREATE TABLE dep_authors (id integer PRIMARY KEY, latest_book_id integer);
+				CREATE TABLE dep_books (id integer PRIMARY KEY, author_id integer, FOREIGN KEY (author_id) REFERENCES dep_authors (id));
+				ALTER TABLE dep_authors ADD FOREIGN KEY (latest_book_id) REFERENCES dep_books (id)`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE dep_books; DROP TABLE dep_authors")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("SET FOREIGN_KEY_CHECKS = 0; DROP TABLE dep_books; DROP TABLE dep_authors; SET FOREIGN_KEY_CHECKS = 1")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("disables foreign key checks for the tables of the cycle only", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(watcher.DidFindForeignKeyCycleCallCount()).To(Equal(1))
+				Expect(watcher.DidFindForeignKeyCycleArgsForCall(0)).To(Equal([]string{"dep_authors", "dep_books"}))
+				Expect(watcher.WillDisableConstraintsCallCount()).To(Equal(1))
+				Expect(watcher.EnableConstraintsDidFinishCallCount()).To(Equal(1))
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM dep_authors JOIN dep_books ON dep_books.author_id = dep_authors.id").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeNumerically("==", 1))
+
+				var checks int
+				err = mysqlRunner.DB().QueryRow("SELECT @@foreign_key_checks").Scan(&checks)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checks).To(Equal(1))
+			})
+		})
+
+		Context("when a table has a serial column", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE table_with_serial (id serial PRIMARY KEY, name text);
+				INSERT INTO table_with_serial (name) VALUES ('a'), ('b');
+				SELECT setval('table_with_serial_id_seq', 10)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_serial (id integer AUTO_INCREMENT PRIMARY KEY, name text)")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_serial")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_serial")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("continues the AUTO_INCREMENT counter after the sequence", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(watcher.DidResetAutoIncrementCallCount()).To(Equal(1))
+				tableName, autoIncrement := watcher.DidResetAutoIncrementArgsForCall(0)
+				Expect(tableName).To(Equal("table_with_serial"))
+				Expect(autoIncrement).To(BeNumerically("==", 11))
+
+				result, err := mysqlRunner.DB().Exec("INSERT INTO table_with_serial (name) VALUES ('c')")
+				Expect(err).NotTo(HaveOccurred())
+				id, err := result.LastInsertId()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(id).To(BeNumerically("==", 11))
+			})
+		})
+
+		Context("when there are sequences that are not owned by a column", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE SEQUENCE invoice_number_seq START 100 INCREMENT 5 MAXVALUE 1000;
+				CREATE SEQUENCE unused_seq;
+				SELECT nextval('invoice_number_seq');
+				SELECT nextval('invoice_number_seq')`)
+				Expect(err).NotTo(HaveOccurred())
+
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithSequenceTable("sequences"))
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP SEQUENCE invoice_number_seq; DROP SEQUENCE unused_seq")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE sequences")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("copies their state into the sequence table", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(watcher.DidMigrateSequenceCallCount()).To(Equal(2))
+
+				var lastValue *int64
+				var startValue, increment, minValue, maxValue int64
+				var cycles bool
+				err = mysqlRunner.DB().QueryRow("SELECT last_value, start_value, increment_by, min_value, max_value, cycles FROM sequences WHERE name = 'invoice_number_seq'").
+					Scan(&lastValue, &startValue, &increment, &minValue, &maxValue, &cycles)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*lastValue).To(BeNumerically("==", 105))
+				Expect(startValue).To(BeNumerically("==", 100))
+				Expect(increment).To(BeNumerically("==", 5))
+				Expect(minValue).To(BeNumerically("==", 1))
+				Expect(maxValue).To(BeNumerically("==", 1000))
+				Expect(cycles).To(BeFalse())
+
+				err = mysqlRunner.DB().QueryRow("SELECT last_value FROM sequences WHERE name = 'unused_seq'").Scan(&lastValue)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lastValue).To(BeNil())
+			})
+
+			Context("when only some tables are included", func() {
+				var filteredPG pg2mysql.DB
+
+				BeforeEach(func() {
+					filter, err := pg2mysql.ParseTableFilter([]string{"table_without_id"}, []string{"*_seq"})
+					Expect(err).NotTo(HaveOccurred())
+
+					filteredPG = pg2mysql.NewPostgreSQLDB(pgRunner.DBName, "", "", "127.0.0.1", 5432, "disable", nil, filter)
+					Expect(filteredPG.Open()).To(Succeed())
+
+					migrator = pg2mysql.NewMigrator(filteredPG, mysql, truncateFirst, watcher, pg2mysql.WithSequenceTable("sequences"))
+				})
+
+				AfterEach(func() {
+					Expect(filteredPG.Close()).To(Succeed())
+				})
+
+				It("still copies every sequence", func() {
+					err := migrator.Migrate()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(watcher.DidMigrateSequenceCallCount()).To(Equal(2))
+
+					var count int64
+					err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM sequences WHERE name IN ('invoice_number_seq', 'unused_seq')").Scan(&count)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(count).To(BeNumerically("==", 2))
+				})
+			})
+		})
+
+		Context("when tables are in several PostgreSQL schemas", func() {
+			var (
+				schemaPG    pg2mysql.DB
+				schemaMySQL pg2mysql.DB
+			)
+
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE SCHEMA billing;
+				CREATE TABLE billing.invoices (id integer PRIMARY KEY, amount integer);
+				INSERT INTO billing.invoices VALUES (1, 10), (2, 20)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE billing_invoices (id integer PRIMARY KEY, amount integer)")
+				Expect(err).NotTo(HaveOccurred())
+
+				schemas := pg2mysql.SchemaMap{
+					"public":  {},
+					"billing": {TablePrefix: "billing_"},
+				}
+
+				schemaMySQL = pg2mysql.NewMySQLDB(mysqlRunner.DBName, "root", "", "127.0.0.1", 3306, "", "", schemas, pg2mysql.TableFilter{})
+				Expect(schemaMySQL.Open()).To(Succeed())
+				schemaPG = pg2mysql.NewPostgreSQLDB(pgRunner.DBName, "", "", "127.0.0.1", 5432, "disable", schemas, pg2mysql.TableFilter{})
+				Expect(schemaPG.Open()).To(Succeed())
+
+				migrator = pg2mysql.NewMigrator(schemaPG, schemaMySQL, truncateFirst, watcher)
+			})
+
+			AfterEach(func() {
+				Expect(schemaMySQL.Close()).To(Succeed())
+				Expect(schemaPG.Close()).To(Succeed())
+
+				_, err := pgRunner.DB().Exec("DROP SCHEMA billing CASCADE")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE billing_invoices")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("migrates and verifies each table where its schema is mapped to", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				inserted := map[string]int64{}
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					inserted[tableName] = recordsInserted
+				}
+				Expect(inserted).To(HaveKeyWithValue("billing.invoices", BeNumerically("==", 2)))
+				Expect(inserted).To(HaveKey("table_with_id"))
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM billing_invoices").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeNumerically("==", 2))
+
+				verifierWatcher := &pg2mysqlfakes.FakeVerifierWatcher{}
+				err = pg2mysql.NewVerifier(schemaPG, schemaMySQL, verifierWatcher).Verify()
+				Expect(err).NotTo(HaveOccurred())
+
+				missing := map[string]int64{}
+				for i := 0; i < verifierWatcher.TableVerificationDidFinishCallCount(); i++ {
+					tableName, missingRows, _ := verifierWatcher.TableVerificationDidFinishArgsForCall(i)
+					missing[tableName] = missingRows
+				}
+				Expect(missing).To(HaveKeyWithValue("billing.invoices", BeZero()))
+			})
+		})
+
+		Context("when a schema is mapped to another MySQL database", func() {
+			var (
+				schemaPG      pg2mysql.DB
+				schemaMySQL   pg2mysql.DB
+				auditDatabase string
+			)
+
+			BeforeEach(func() {
+				auditDatabase = mysqlRunner.DBName + "_audit"
+
+				_, err := pgRunner.DB().Exec(`
+				CREATE SCHEMA audit;
+				CREATE TABLE audit.events (id integer PRIMARY KEY, name text);
+				INSERT INTO audit.events VALUES (1, 'login'), (2, 'logout')`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(fmt.Sprintf("CREATE DATABASE %s; CREATE TABLE %s.events (id integer PRIMARY KEY, name text)", auditDatabase, auditDatabase))
+				Expect(err).NotTo(HaveOccurred())
+
+				schemas := pg2mysql.SchemaMap{
+					"public": {},
+					"audit":  {Database: auditDatabase},
+				}
+				Expect(schemas.Validate(mysqlRunner.DBName)).To(Succeed())
+
+				schemaMySQL = pg2mysql.NewMySQLDB(mysqlRunner.DBName, "root", "", "127.0.0.1", 3306, "", "", schemas, pg2mysql.TableFilter{})
+				Expect(schemaMySQL.Open()).To(Succeed())
+				schemaPG = pg2mysql.NewPostgreSQLDB(pgRunner.DBName, "", "", "127.0.0.1", 5432, "disable", schemas, pg2mysql.TableFilter{})
+				Expect(schemaPG.Open()).To(Succeed())
+
+				migrator = pg2mysql.NewMigrator(schemaPG, schemaMySQL, truncateFirst, watcher)
+			})
+
+			AfterEach(func() {
+				Expect(schemaMySQL.Close()).To(Succeed())
+				Expect(schemaPG.Close()).To(Succeed())
+
+				_, err := pgRunner.DB().Exec("DROP SCHEMA audit CASCADE")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(fmt.Sprintf("DROP DATABASE %s", auditDatabase))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("migrates and verifies the tables of the schema in that database", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				inserted := map[string]int64{}
+				for i := 0; i < watcher.TableMigrationDidFinishCallCount(); i++ {
+					tableName, recordsInserted := watcher.TableMigrationDidFinishArgsForCall(i)
+					inserted[tableName] = recordsInserted
+				}
+				Expect(inserted).To(HaveKeyWithValue("audit.events", BeNumerically("==", 2)))
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow(fmt.Sprintf("SELECT COUNT(1) FROM %s.events", auditDatabase)).Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(BeNumerically("==", 2))
+
+				verifierWatcher := &pg2mysqlfakes.FakeVerifierWatcher{}
+				err = pg2mysql.NewVerifier(schemaPG, schemaMySQL, verifierWatcher).Verify()
+				Expect(err).NotTo(HaveOccurred())
+
+				missing := map[string]int64{}
+				for i := 0; i < verifierWatcher.TableVerificationDidFinishCallCount(); i++ {
+					tableName, missingRows, _ := verifierWatcher.TableVerificationDidFinishArgsForCall(i)
+					missing[tableName] = missingRows
+				}
+				Expect(missing).To(HaveKeyWithValue("audit.events", BeZero()))
+			})
+		})
+
+		Context("when tables are excluded", func() {
+			var filteredPG pg2mysql.DB
+
+			BeforeEach(func() {
+				filter, err := pg2mysql.ParseTableFilter(nil, []string{"table_with_*"})
+				Expect(err).NotTo(HaveOccurred())
+
+				filteredPG = pg2mysql.NewPostgreSQLDB(pgRunner.DBName, "", "", "127.0.0.1", 5432, "disable", nil, filter)
+				Expect(filteredPG.Open()).To(Succeed())
+
+				migrator = pg2mysql.NewMigrator(filteredPG, mysql, truncateFirst, watcher)
+			})
+
+			AfterEach(func() {
+				Expect(filteredPG.Close()).To(Succeed())
+			})
+
+			It("only migrates the other tables", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(watcher.TableMigrationDidStartCallCount()).To(Equal(1))
+				Expect(watcher.TableMigrationDidStartArgsForCall(0)).To(Equal("table_without_id"))
+			})
+		})
+
+		Context("when there are infinite dates and times", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_infinity (id integer PRIMARY KEY, expires_at timestamp)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_infinity (id integer PRIMARY KEY, expires_at datetime)")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_infinity VALUES (1, 'infinity'), (2, '-infinity'), (3, '2017-01-01 00:00:00')")
+				Expect(err).NotTo(HaveOccurred())
+
+				mapping := pg2mysql.InfinityMapping{
+					"infinity":  time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+					"-infinity": nil,
+				}
+				migrator = pg2mysql.NewMigrator(pg, mysql, truncateFirst, watcher, pg2mysql.WithInfinityMapping(mapping))
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_infinity")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_infinity")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("stores them as mapped", func() {
+				err := migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				rows, err := mysqlRunner.DB().Query("SELECT id, expires_at FROM table_with_infinity ORDER BY id")
+				Expect(err).NotTo(HaveOccurred())
+				defer rows.Close()
+
+				var expiresAt []*time.Time
+				for rows.Next() {
+					var id int
+					var t *time.Time
+					Expect(rows.Scan(&id, &t)).To(Succeed())
+					expiresAt = append(expiresAt, t)
+				}
+				Expect(rows.Err()).NotTo(HaveOccurred())
+
+				Expect(expiresAt).To(HaveLen(3))
+				Expect(*expiresAt[0]).To(BeTemporally("==", time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)))
+				Expect(expiresAt[1]).To(BeNil())
+				Expect(*expiresAt[2]).To(BeTemporally("==", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)))
+			})
+		})
+
 		Context("when there is compatible data in postgres in a table without an 'id' column", func() {
 			var currentTime time.Time
 
@@ -268,6 +1219,50 @@ var _ = Describe("Migrator", func() {
 				Expect(created_at.Format(time.RFC1123Z)).To(Equal(currentTime.Format(time.RFC1123Z)))
 				Expect(truthiness).To(BeTrue())
 			})
+
+			It("inserts each missing copy of a duplicated row", func() {
+				_, err := pgRunner.DB().Exec("INSERT INTO table_without_id SELECT * FROM table_without_id")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_without_id SELECT * FROM table_without_id")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = mysqlRunner.DB().Exec("INSERT INTO table_without_id (name, ci_name, created_at, truthiness) VALUES (?, ?, ?, ?)", "some'name", "some'ci'name", currentTime.Truncate(time.Second), true)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var count int64
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_without_id").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(4)))
+
+				err = migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1) FROM table_without_id").Scan(&count)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(4)))
+			})
+
+			It("does not insert a row again once MySQL has rounded its fractional seconds", func() {
+				_, err := pgRunner.DB().Exec("INSERT INTO table_without_id (name, ci_name, created_at, truthiness) VALUES ('rounded', 'rounded', '2020-01-01 00:00:00.7', true)")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				err = migrator.Migrate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var count int64
+				var created_at time.Time
+				err = mysqlRunner.DB().QueryRow("SELECT COUNT(1), MAX(created_at) FROM table_without_id WHERE name = 'rounded'").Scan(&count, &created_at)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(int64(1)))
+				Expect(created_at.Format("2006-01-02 15:04:05")).To(Equal("2020-01-01 00:00:01"))
+			})
 		})
 	})
 })
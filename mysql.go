@@ -3,16 +3,29 @@ package pg2mysql
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/go-sql-driver/mysql"
 )
 
+// defaultMySQLCharset is the connection character set used when none is
+// configured. Unlike utf8, it holds every Unicode character.
+const defaultMySQLCharset = "utf8mb4"
+
+// NewMySQLDB returns a DB for the given MySQL database. The connection uses
+// charset, or utf8mb4 if it is empty, unless a collation is given, which
+// also determines the character set. The tables of the PostgreSQL schemas
+// of schemas that tables selects are found where they are mapped to.
 func NewMySQLDB(
 	database string,
 	username string,
 	password string,
 	host string,
 	port int,
+	charset string,
+	collation string,
+	schemas SchemaMap,
+	tables TableFilter,
 ) DB {
 	config := mysql.Config{
 		User:            username,
@@ -22,21 +35,36 @@ func NewMySQLDB(
 		Addr:            fmt.Sprintf("%s:%d", host, port),
 		MultiStatements: true,
 		Params: map[string]string{
-			"charset":   "utf8",
 			"parseTime": "True",
 		},
 	}
 
+	// SET NAMES, which the charset parameter issues, would reset the
+	// collation to the default of the character set
+	if collation != "" {
+		config.Collation = collation
+	} else if charset != "" {
+		config.Params["charset"] = charset
+	} else {
+		config.Params["charset"] = defaultMySQLCharset
+	}
+
 	return &mySQLDB{
-		dsn:    config.FormatDSN(),
-		dbName: database,
+		dsn:      config.FormatDSN(),
+		dbName:   database,
+		schemas:  schemas,
+		mappings: schemas.mappings(database),
+		tables:   tables,
 	}
 }
 
 type mySQLDB struct {
-	dsn    string
-	db     *sql.DB
-	dbName string
+	dsn      string
+	db       *sql.DB
+	dbName   string
+	schemas  SchemaMap
+	mappings []schemaMapping
+	tables   TableFilter
 }
 
 func (m *mySQLDB) Open() error {
@@ -50,19 +78,72 @@ func (m *mySQLDB) Open() error {
 	return nil
 }
 
+// Clone returns an unopened DB with the same configuration.
+func (m *mySQLDB) Clone() DB {
+	return &mySQLDB{
+		dsn:      m.dsn,
+		dbName:   m.dbName,
+		schemas:  m.schemas,
+		mappings: m.mappings,
+		tables:   m.tables,
+	}
+}
+
 func (m *mySQLDB) Close() error {
 	return m.db.Close()
 }
 
 func (m *mySQLDB) GetSchemaRows() (*sql.Rows, error) {
+	condition, args := mysqlSchemaCondition(m, "table_schema")
 	query := `
-	SELECT table_name,
+	SELECT table_schema,
+				 table_name,
 				 column_name,
 				 data_type,
-				 character_maximum_length
+				 character_maximum_length,
+				 is_nullable = 'YES',
+				 column_default,
+				 numeric_precision,
+				 numeric_scale,
+				 datetime_precision,
+				 column_type LIKE '%unsigned%',
+				 character_set_name,
+				 character_octet_length
 	FROM   information_schema.columns
-	WHERE  table_schema = ?`
-	rows, err := m.db.Query(query, m.dbName)
+	WHERE  ` + condition
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// GetKeyRows lists the columns of the primary key and unique indexes on NOT
+// NULL columns of each table, with the primary key first.
+func (m *mySQLDB) GetKeyRows() (*sql.Rows, error) {
+	condition, args := mysqlSchemaCondition(m, "s.table_schema")
+	query := `
+	SELECT s.table_schema,
+	       s.table_name,
+	       s.index_name,
+	       s.column_name
+	FROM   information_schema.statistics s
+	WHERE  ` + condition + `
+	       AND s.non_unique = 0
+	       AND NOT EXISTS (SELECT 1
+	                       FROM   information_schema.statistics n
+	                       WHERE  n.table_schema = s.table_schema
+	                              AND n.table_name = s.table_name
+	                              AND n.index_name = s.index_name
+	                              AND ( n.nullable = 'YES'
+	                                     OR n.sub_part IS NOT NULL ))
+	ORDER  BY s.table_schema,
+	          s.table_name,
+	          s.index_name = 'PRIMARY' DESC,
+	          s.index_name,
+	          s.seq_in_index`
+	rows, err := m.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -74,10 +155,62 @@ func (m *mySQLDB) DB() *sql.DB {
 	return m.db
 }
 
+func (m *mySQLDB) Querier() Querier {
+	return m.db
+}
+
 func (m *mySQLDB) ColumnNameForSelect(name string) string {
 	return fmt.Sprintf("`%s`", name)
 }
 
+func (m *mySQLDB) Schemas() []string {
+	return m.schemas.databases(m.dbName)
+}
+
+// mysqlSchemaCondition returns a condition restricting column, of a query
+// of information_schema, to the databases of db, with its arguments.
+func mysqlSchemaCondition(db DB, column string) (string, []interface{}) {
+	schemas := db.Schemas()
+	args := make([]interface{}, len(schemas))
+	for i, schema := range schemas {
+		args[i] = schema
+	}
+
+	return fmt.Sprintf("%s IN (%s)", column, strings.TrimSuffix(strings.Repeat("?,", len(schemas)), ",")), args
+}
+
+// TableName returns the qualified name of the PostgreSQL table that is
+// mapped to the table of database, unless no migrated schema is mapped to
+// it or the table filter does not select it. Longer table prefixes are
+// matched first.
+func (m *mySQLDB) TableName(database, table string) (string, bool) {
+	for _, mapping := range m.mappings {
+		if mapping.database == database && strings.HasPrefix(table, mapping.prefix) {
+			name := qualifiedTableName(mapping.schema, strings.TrimPrefix(table, mapping.prefix))
+			if !m.tables.Match(name) {
+				return "", false
+			}
+
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// TableNameForQuery returns the identifier of the MySQL table that the
+// PostgreSQL table with the given qualified name is mapped to, qualified by
+// its database unless that is the database being migrated to.
+func (m *mySQLDB) TableNameForQuery(name string) string {
+	schema, table := splitTableName(name)
+	database, prefix := m.schemas.target(schema, m.dbName)
+	if database == m.dbName {
+		return prefix + table
+	}
+
+	return fmt.Sprintf("%s.%s%s", database, prefix, table)
+}
+
 func (m *mySQLDB) EnableConstraints() error {
 	_, err := m.db.Exec("SET FOREIGN_KEY_CHECKS = 1;")
 	return err
@@ -87,3 +220,11 @@ func (m *mySQLDB) DisableConstraints() error {
 	_, err := m.db.Exec("SET FOREIGN_KEY_CHECKS = 0;")
 	return err
 }
+
+func (m *mySQLDB) BeginSnapshot() error {
+	panic("not implemented")
+}
+
+func (m *mySQLDB) EndSnapshot() error {
+	panic("not implemented")
+}
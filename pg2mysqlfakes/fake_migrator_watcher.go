@@ -31,6 +31,11 @@ type FakeMigratorWatcher struct {
 	enableConstraintsDidFailWithErrorArgsForCall []struct {
 		err error
 	}
+	DidFindForeignKeyCycleStub        func(tableNames []string)
+	didFindForeignKeyCycleMutex       sync.RWMutex
+	didFindForeignKeyCycleArgsForCall []struct {
+		tableNames []string
+	}
 	WillTruncateTableStub        func(tableName string)
 	willTruncateTableMutex       sync.RWMutex
 	willTruncateTableArgsForCall []struct {
@@ -52,6 +57,19 @@ type FakeMigratorWatcher struct {
 		tableName       string
 		recordsInserted int64
 	}
+	TableChunkMigrationDidFinishStub        func(tableName string, chunk int, chunks int, recordsInserted int64)
+	tableChunkMigrationDidFinishMutex       sync.RWMutex
+	tableChunkMigrationDidFinishArgsForCall []struct {
+		tableName       string
+		chunk           int
+		chunks          int
+		recordsInserted int64
+	}
+	DidSkipCompletedTableStub        func(tableName string)
+	didSkipCompletedTableMutex       sync.RWMutex
+	didSkipCompletedTableArgsForCall []struct {
+		tableName string
+	}
 	DidMigrateRowStub        func(tableName string)
 	didMigrateRowMutex       sync.RWMutex
 	didMigrateRowArgsForCall []struct {
@@ -63,6 +81,17 @@ type FakeMigratorWatcher struct {
 		tableName string
 		err       error
 	}
+	DidResetAutoIncrementStub        func(tableName string, autoIncrement int64)
+	didResetAutoIncrementMutex       sync.RWMutex
+	didResetAutoIncrementArgsForCall []struct {
+		tableName     string
+		autoIncrement int64
+	}
+	DidMigrateSequenceStub        func(sequenceName string)
+	didMigrateSequenceMutex       sync.RWMutex
+	didMigrateSequenceArgsForCall []struct {
+		sequenceName string
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -187,6 +216,35 @@ func (fake *FakeMigratorWatcher) EnableConstraintsDidFailWithErrorArgsForCall(i
 	return fake.enableConstraintsDidFailWithErrorArgsForCall[i].err
 }
 
+func (fake *FakeMigratorWatcher) DidFindForeignKeyCycle(tableNames []string) {
+	var tableNamesCopy []string
+	if tableNames != nil {
+		tableNamesCopy = make([]string, len(tableNames))
+		copy(tableNamesCopy, tableNames)
+	}
+	fake.didFindForeignKeyCycleMutex.Lock()
+	fake.didFindForeignKeyCycleArgsForCall = append(fake.didFindForeignKeyCycleArgsForCall, struct {
+		tableNames []string
+	}{tableNamesCopy})
+	fake.recordInvocation("DidFindForeignKeyCycle", []interface{}{tableNamesCopy})
+	fake.didFindForeignKeyCycleMutex.Unlock()
+	if fake.DidFindForeignKeyCycleStub != nil {
+		fake.DidFindForeignKeyCycleStub(tableNames)
+	}
+}
+
+func (fake *FakeMigratorWatcher) DidFindForeignKeyCycleCallCount() int {
+	fake.didFindForeignKeyCycleMutex.RLock()
+	defer fake.didFindForeignKeyCycleMutex.RUnlock()
+	return len(fake.didFindForeignKeyCycleArgsForCall)
+}
+
+func (fake *FakeMigratorWatcher) DidFindForeignKeyCycleArgsForCall(i int) []string {
+	fake.didFindForeignKeyCycleMutex.RLock()
+	defer fake.didFindForeignKeyCycleMutex.RUnlock()
+	return fake.didFindForeignKeyCycleArgsForCall[i].tableNames
+}
+
 func (fake *FakeMigratorWatcher) WillTruncateTable(tableName string) {
 	fake.willTruncateTableMutex.Lock()
 	fake.willTruncateTableArgsForCall = append(fake.willTruncateTableArgsForCall, struct {
@@ -284,6 +342,57 @@ func (fake *FakeMigratorWatcher) TableMigrationDidFinishArgsForCall(i int) (stri
 	return fake.tableMigrationDidFinishArgsForCall[i].tableName, fake.tableMigrationDidFinishArgsForCall[i].recordsInserted
 }
 
+func (fake *FakeMigratorWatcher) TableChunkMigrationDidFinish(tableName string, chunk int, chunks int, recordsInserted int64) {
+	fake.tableChunkMigrationDidFinishMutex.Lock()
+	fake.tableChunkMigrationDidFinishArgsForCall = append(fake.tableChunkMigrationDidFinishArgsForCall, struct {
+		tableName       string
+		chunk           int
+		chunks          int
+		recordsInserted int64
+	}{tableName, chunk, chunks, recordsInserted})
+	fake.recordInvocation("TableChunkMigrationDidFinish", []interface{}{tableName, chunk, chunks, recordsInserted})
+	fake.tableChunkMigrationDidFinishMutex.Unlock()
+	if fake.TableChunkMigrationDidFinishStub != nil {
+		fake.TableChunkMigrationDidFinishStub(tableName, chunk, chunks, recordsInserted)
+	}
+}
+
+func (fake *FakeMigratorWatcher) TableChunkMigrationDidFinishCallCount() int {
+	fake.tableChunkMigrationDidFinishMutex.RLock()
+	defer fake.tableChunkMigrationDidFinishMutex.RUnlock()
+	return len(fake.tableChunkMigrationDidFinishArgsForCall)
+}
+
+func (fake *FakeMigratorWatcher) TableChunkMigrationDidFinishArgsForCall(i int) (string, int, int, int64) {
+	fake.tableChunkMigrationDidFinishMutex.RLock()
+	defer fake.tableChunkMigrationDidFinishMutex.RUnlock()
+	return fake.tableChunkMigrationDidFinishArgsForCall[i].tableName, fake.tableChunkMigrationDidFinishArgsForCall[i].chunk, fake.tableChunkMigrationDidFinishArgsForCall[i].chunks, fake.tableChunkMigrationDidFinishArgsForCall[i].recordsInserted
+}
+
+func (fake *FakeMigratorWatcher) DidSkipCompletedTable(tableName string) {
+	fake.didSkipCompletedTableMutex.Lock()
+	fake.didSkipCompletedTableArgsForCall = append(fake.didSkipCompletedTableArgsForCall, struct {
+		tableName string
+	}{tableName})
+	fake.recordInvocation("DidSkipCompletedTable", []interface{}{tableName})
+	fake.didSkipCompletedTableMutex.Unlock()
+	if fake.DidSkipCompletedTableStub != nil {
+		fake.DidSkipCompletedTableStub(tableName)
+	}
+}
+
+func (fake *FakeMigratorWatcher) DidSkipCompletedTableCallCount() int {
+	fake.didSkipCompletedTableMutex.RLock()
+	defer fake.didSkipCompletedTableMutex.RUnlock()
+	return len(fake.didSkipCompletedTableArgsForCall)
+}
+
+func (fake *FakeMigratorWatcher) DidSkipCompletedTableArgsForCall(i int) string {
+	fake.didSkipCompletedTableMutex.RLock()
+	defer fake.didSkipCompletedTableMutex.RUnlock()
+	return fake.didSkipCompletedTableArgsForCall[i].tableName
+}
+
 func (fake *FakeMigratorWatcher) DidMigrateRow(tableName string) {
 	fake.didMigrateRowMutex.Lock()
 	fake.didMigrateRowArgsForCall = append(fake.didMigrateRowArgsForCall, struct {
@@ -333,6 +442,55 @@ func (fake *FakeMigratorWatcher) DidFailToMigrateRowWithErrorArgsForCall(i int)
 	return fake.didFailToMigrateRowWithErrorArgsForCall[i].tableName, fake.didFailToMigrateRowWithErrorArgsForCall[i].err
 }
 
+func (fake *FakeMigratorWatcher) DidResetAutoIncrement(tableName string, autoIncrement int64) {
+	fake.didResetAutoIncrementMutex.Lock()
+	fake.didResetAutoIncrementArgsForCall = append(fake.didResetAutoIncrementArgsForCall, struct {
+		tableName     string
+		autoIncrement int64
+	}{tableName, autoIncrement})
+	fake.recordInvocation("DidResetAutoIncrement", []interface{}{tableName, autoIncrement})
+	fake.didResetAutoIncrementMutex.Unlock()
+	if fake.DidResetAutoIncrementStub != nil {
+		fake.DidResetAutoIncrementStub(tableName, autoIncrement)
+	}
+}
+
+func (fake *FakeMigratorWatcher) DidResetAutoIncrementCallCount() int {
+	fake.didResetAutoIncrementMutex.RLock()
+	defer fake.didResetAutoIncrementMutex.RUnlock()
+	return len(fake.didResetAutoIncrementArgsForCall)
+}
+
+func (fake *FakeMigratorWatcher) DidResetAutoIncrementArgsForCall(i int) (string, int64) {
+	fake.didResetAutoIncrementMutex.RLock()
+	defer fake.didResetAutoIncrementMutex.RUnlock()
+	return fake.didResetAutoIncrementArgsForCall[i].tableName, fake.didResetAutoIncrementArgsForCall[i].autoIncrement
+}
+
+func (fake *FakeMigratorWatcher) DidMigrateSequence(sequenceName string) {
+	fake.didMigrateSequenceMutex.Lock()
+	fake.didMigrateSequenceArgsForCall = append(fake.didMigrateSequenceArgsForCall, struct {
+		sequenceName string
+	}{sequenceName})
+	fake.recordInvocation("DidMigrateSequence", []interface{}{sequenceName})
+	fake.didMigrateSequenceMutex.Unlock()
+	if fake.DidMigrateSequenceStub != nil {
+		fake.DidMigrateSequenceStub(sequenceName)
+	}
+}
+
+func (fake *FakeMigratorWatcher) DidMigrateSequenceCallCount() int {
+	fake.didMigrateSequenceMutex.RLock()
+	defer fake.didMigrateSequenceMutex.RUnlock()
+	return len(fake.didMigrateSequenceArgsForCall)
+}
+
+func (fake *FakeMigratorWatcher) DidMigrateSequenceArgsForCall(i int) string {
+	fake.didMigrateSequenceMutex.RLock()
+	defer fake.didMigrateSequenceMutex.RUnlock()
+	return fake.didMigrateSequenceArgsForCall[i].sequenceName
+}
+
 func (fake *FakeMigratorWatcher) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -350,6 +508,8 @@ func (fake *FakeMigratorWatcher) Invocations() map[string][][]interface{} {
 	defer fake.enableConstraintsDidFinishMutex.RUnlock()
 	fake.enableConstraintsDidFailWithErrorMutex.RLock()
 	defer fake.enableConstraintsDidFailWithErrorMutex.RUnlock()
+	fake.didFindForeignKeyCycleMutex.RLock()
+	defer fake.didFindForeignKeyCycleMutex.RUnlock()
 	fake.willTruncateTableMutex.RLock()
 	defer fake.willTruncateTableMutex.RUnlock()
 	fake.truncateTableDidFinishMutex.RLock()
@@ -358,10 +518,18 @@ func (fake *FakeMigratorWatcher) Invocations() map[string][][]interface{} {
 	defer fake.tableMigrationDidStartMutex.RUnlock()
 	fake.tableMigrationDidFinishMutex.RLock()
 	defer fake.tableMigrationDidFinishMutex.RUnlock()
+	fake.tableChunkMigrationDidFinishMutex.RLock()
+	defer fake.tableChunkMigrationDidFinishMutex.RUnlock()
+	fake.didSkipCompletedTableMutex.RLock()
+	defer fake.didSkipCompletedTableMutex.RUnlock()
 	fake.didMigrateRowMutex.RLock()
 	defer fake.didMigrateRowMutex.RUnlock()
 	fake.didFailToMigrateRowWithErrorMutex.RLock()
 	defer fake.didFailToMigrateRowWithErrorMutex.RUnlock()
+	fake.didResetAutoIncrementMutex.RLock()
+	defer fake.didResetAutoIncrementMutex.RUnlock()
+	fake.didMigrateSequenceMutex.RLock()
+	defer fake.didMigrateSequenceMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -4,9 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 
-	_ "github.com/lib/pq" // register postgres driver
+	"github.com/lib/pq"
 )
 
+// NewPostgreSQLDB returns a DB for the given PostgreSQL database, of which
+// the tables in the schemas of schemas that tables selects are migrated.
 func NewPostgreSQLDB(
 	database string,
 	username string,
@@ -14,6 +16,8 @@ func NewPostgreSQLDB(
 	host string,
 	port int,
 	sslMode string,
+	schemas SchemaMap,
+	tables TableFilter,
 ) DB {
 	dsn := fmt.Sprintf("dbname=%s host=%s port=%d sslmode=%s", database, host, port, sslMode)
 
@@ -25,15 +29,21 @@ func NewPostgreSQLDB(
 	}
 
 	return &postgreSQLDB{
-		dsn:    dsn,
-		dbName: database,
+		dsn:     dsn,
+		dbName:  database,
+		schemas: schemas,
+		tables:  tables,
 	}
 }
 
 type postgreSQLDB struct {
-	dbName string
-	db     *sql.DB
-	dsn    string
+	dbName     string
+	db         *sql.DB
+	dsn        string
+	schemas    SchemaMap
+	tables     TableFilter
+	snapshotID string
+	tx         *sql.Tx
 }
 
 func (p *postgreSQLDB) Open() error {
@@ -44,28 +54,173 @@ func (p *postgreSQLDB) Open() error {
 
 	p.db = db
 
+	if p.snapshotID != "" {
+		return p.joinSnapshot()
+	}
+
+	return nil
+}
+
+// Clone returns an unopened DB with the same configuration. If a snapshot
+// has been started, the clone joins it when opened.
+func (p *postgreSQLDB) Clone() DB {
+	return &postgreSQLDB{
+		dsn:        p.dsn,
+		dbName:     p.dbName,
+		schemas:    p.schemas,
+		tables:     p.tables,
+		snapshotID: p.snapshotID,
+	}
+}
+
+// BeginSnapshot starts a read-only transaction in which every subsequent
+// query through Querier sees the database as it was when the snapshot
+// began. The snapshot is exported so that clones opened afterwards see the
+// same data.
+func (p *postgreSQLDB) BeginSnapshot() error {
+	err := p.beginTransaction()
+	if err != nil {
+		return err
+	}
+
+	err = p.tx.QueryRow("SELECT pg_export_snapshot()").Scan(&p.snapshotID)
+	if err != nil {
+		p.rollback()
+		return fmt.Errorf("failed to export snapshot: %s", err)
+	}
+
+	return nil
+}
+
+func (p *postgreSQLDB) joinSnapshot() error {
+	err := p.beginTransaction()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.tx.Exec(fmt.Sprintf("SET TRANSACTION SNAPSHOT '%s'", p.snapshotID))
+	if err != nil {
+		p.rollback()
+		return fmt.Errorf("failed to join snapshot: %s", err)
+	}
+
 	return nil
 }
 
+// EndSnapshot ends the snapshot transaction and returns the DB to normal use.
+func (p *postgreSQLDB) EndSnapshot() error {
+	p.snapshotID = ""
+
+	if p.tx == nil {
+		return nil
+	}
+
+	err := p.tx.Commit()
+	p.tx = nil
+
+	return err
+}
+
+// beginTransaction opens a REPEATABLE READ READ ONLY transaction, which
+// holds a connection of its own until it ends. A failure of that connection
+// fails the queries of the transaction rather than moving them to another
+// connection.
+func (p *postgreSQLDB) beginTransaction() error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %s", err)
+	}
+
+	_, err = tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY")
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to begin transaction: %s", err)
+	}
+
+	p.tx = tx
+
+	return nil
+}
+
+func (p *postgreSQLDB) rollback() {
+	p.tx.Rollback()
+	p.tx = nil
+}
+
 func (p *postgreSQLDB) Close() error {
+	if p.tx != nil {
+		p.rollback()
+	}
+
 	return p.db.Close()
 }
 
 func (p *postgreSQLDB) GetSchemaRows() (*sql.Rows, error) {
 	stmt := `
-	SELECT t1.table_name,
+	SELECT t1.table_schema,
+	       t1.table_name,
 	       t1.column_name,
 	       t1.data_type,
-	       t1.character_maximum_length
+	       t1.character_maximum_length,
+	       t1.is_nullable = 'YES',
+	       t1.column_default,
+	       t1.numeric_precision,
+	       t1.numeric_scale,
+	       t1.datetime_precision,
+	       false,
+	       t1.character_set_name,
+	       t1.character_octet_length
 	FROM   information_schema.columns t1
 	       JOIN information_schema.tables t2
-	         ON t2.table_name = t1.table_name
+	         ON t2.table_schema = t1.table_schema
+	            AND t2.table_name = t1.table_name
 	            AND t2.table_type = 'BASE TABLE'
-	WHERE  t1.table_schema = 'public'
-	       AND t1.table_name NOT IN ('schema_migrations')
+	WHERE  t1.table_schema = ANY ($2)
 	       AND t1.table_catalog = $1`
 
-	rows, err := p.db.Query(stmt, p.dbName)
+	rows, err := p.Querier().Query(stmt, p.dbName, pq.Array(p.Schemas()))
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// GetKeyRows lists the columns of the primary key and unique indexes on NOT
+// NULL columns of each table, with the primary key first.
+func (p *postgreSQLDB) GetKeyRows() (*sql.Rows, error) {
+	stmt := `
+	SELECT n.nspname,
+	       t.relname,
+	       i.relname,
+	       a.attname
+	FROM   pg_index x
+	       JOIN pg_class t
+	         ON t.oid = x.indrelid
+	       JOIN pg_class i
+	         ON i.oid = x.indexrelid
+	       JOIN pg_namespace n
+	         ON n.oid = t.relnamespace
+	       CROSS JOIN LATERAL generate_subscripts(x.indkey::int2[], 1) AS k(n)
+	       JOIN pg_attribute a
+	         ON a.attrelid = t.oid
+	            AND a.attnum = (x.indkey::int2[])[k.n]
+	WHERE  n.nspname = ANY ($1)
+	       AND x.indisunique
+	       AND x.indpred IS NULL
+	       AND x.indexprs IS NULL
+	       AND NOT EXISTS (SELECT 1
+	                       FROM   pg_attribute na
+	                       WHERE  na.attrelid = t.oid
+	                              AND na.attnum = ANY (x.indkey)
+	                              AND NOT na.attnotnull)
+	ORDER  BY n.nspname,
+	          t.relname,
+	          x.indisprimary DESC,
+	          i.relname,
+	          k.n`
+
+	rows, err := p.Querier().Query(stmt, pq.Array(p.Schemas()))
 	if err != nil {
 		return nil, err
 	}
@@ -77,10 +232,45 @@ func (p *postgreSQLDB) DB() *sql.DB {
 	return p.db
 }
 
+// Querier returns the transaction of the snapshot while one is open, and
+// the connection pool otherwise.
+func (p *postgreSQLDB) Querier() Querier {
+	if p.tx != nil {
+		return p.tx
+	}
+
+	return p.db
+}
+
 func (p *postgreSQLDB) ColumnNameForSelect(name string) string {
 	return name
 }
 
+func (p *postgreSQLDB) Schemas() []string {
+	return p.schemas.Schemas()
+}
+
+// TableName returns the qualified name of the table of schema, unless the
+// schema is not migrated or the table is filtered out.
+func (p *postgreSQLDB) TableName(schema, table string) (string, bool) {
+	if !p.schemas.includes(schema) {
+		return "", false
+	}
+
+	name := qualifiedTableName(schema, table)
+	if !p.tables.Match(name) {
+		return "", false
+	}
+
+	return name, true
+}
+
+// TableNameForQuery returns name, since qualified names are valid
+// identifiers in PostgreSQL.
+func (p *postgreSQLDB) TableNameForQuery(name string) string {
+	return name
+}
+
 func (p *postgreSQLDB) EnableConstraints() error {
 	panic("not implemented")
 }
@@ -0,0 +1,69 @@
+package pg2mysql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("PostgreSQLDB", func() {
+	var pg pg2mysql.DB
+
+	BeforeEach(func() {
+		pg = pg2mysql.NewPostgreSQLDB(
+			pgRunner.DBName,
+			"",
+			"",
+			"127.0.0.1",
+			5432,
+			"disable",
+			nil,
+			pg2mysql.TableFilter{},
+		)
+		err := pg.Open()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := pg.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("BeginSnapshot", func() {
+		countRows := func(db pg2mysql.DB) int {
+			var count int
+			err := db.Querier().QueryRow("SELECT count(1) FROM table_with_id").Scan(&count)
+			Expect(err).NotTo(HaveOccurred())
+			return count
+		}
+
+		BeforeEach(func() {
+			err := pg.BeginSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = pgRunner.DB().Exec("INSERT INTO table_with_id (id, name, ci_name, created_at, truthiness) VALUES (3, 'some-name', 'some-ci-name', now(), false)")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("does not see changes made after the snapshot began", func() {
+			Expect(countRows(pg)).To(Equal(0))
+
+			err := pg.EndSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(countRows(pg)).To(Equal(1))
+		})
+
+		It("shares the snapshot with clones", func() {
+			clone := pg.Clone()
+			err := clone.Open()
+			Expect(err).NotTo(HaveOccurred())
+			defer clone.Close()
+
+			Expect(countRows(clone)).To(Equal(0))
+
+			err = pg.EndSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
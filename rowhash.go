@@ -0,0 +1,103 @@
+package pg2mysql
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type rowHash [sha256.Size]byte
+
+// hashRow hashes the values of a row scanned from either database. Values
+// are converted to a canonical form first, since the drivers return the same
+// data as different types: MySQL returns most values as text and booleans
+// as 0 or 1. Times are hashed in UTC with their fractional seconds, so
+// times from PostgreSQL must be rounded to the precision of their MySQL
+// column first.
+func hashRow(h hash.Hash, values []interface{}) rowHash {
+	h.Reset()
+
+	var buf []byte
+	for _, v := range values {
+		buf = buf[:0]
+
+		switch v := v.(type) {
+		case nil:
+			// distinguishes NULL from an empty value
+			h.Write([]byte{0})
+			continue
+		case bool:
+			if v {
+				buf = append(buf, '1')
+			} else {
+				buf = append(buf, '0')
+			}
+		case int64:
+			buf = strconv.AppendInt(buf, v, 10)
+		case float64:
+			buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+		case time.Time:
+			buf = v.UTC().AppendFormat(buf, "2006-01-02 15:04:05.999999999")
+		case []byte:
+			buf = append(buf, v...)
+		case string:
+			buf = append(buf, v...)
+		default:
+			buf = append(buf, fmt.Sprint(v)...)
+		}
+
+		var length [9]byte
+		length[0] = 1
+		binary.BigEndian.PutUint64(length[1:], uint64(len(buf)))
+		h.Write(length[:])
+		h.Write(buf)
+	}
+
+	var sum rowHash
+	h.Sum(sum[:0])
+	return sum
+}
+
+// countRows returns the number of rows in table with each distinct hash.
+// The map holds an entry of at least 40 bytes for every distinct row, so
+// its size grows with the table.
+func countRows(db DB, table *Table) (map[rowHash]int64, error) {
+	columnNamesForSelect := make([]string, len(table.Columns))
+	values := make([]interface{}, len(table.Columns))
+	scanArgs := make([]interface{}, len(table.Columns))
+	for i := range table.Columns {
+		columnNamesForSelect[i] = db.ColumnNameForSelect(table.Columns[i].Name)
+		scanArgs[i] = &values[i]
+	}
+
+	stmt := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columnNamesForSelect, ","), db.TableNameForQuery(table.Name))
+	rows, err := db.Querier().Query(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select rows: %s", err)
+	}
+
+	h := sha256.New()
+	counts := map[rowHash]int64{}
+	for rows.Next() {
+		if err = rows.Scan(scanArgs...); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %s", err)
+		}
+
+		counts[hashRow(h, values)]++
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed iterating through rows: %s", err)
+	}
+
+	if err = rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed closing rows: %s", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,281 @@
+package pg2mysql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type SchemaDifferenceKind string
+
+const (
+	MissingTable        SchemaDifferenceKind = "missing table"
+	ExtraTable          SchemaDifferenceKind = "extra table"
+	MissingColumn       SchemaDifferenceKind = "missing column"
+	ExtraColumn         SchemaDifferenceKind = "extra column"
+	TypeMismatch        SchemaDifferenceKind = "type mismatch"
+	NullabilityMismatch SchemaDifferenceKind = "nullability mismatch"
+	DefaultMismatch     SchemaDifferenceKind = "default mismatch"
+	KeyMismatch         SchemaDifferenceKind = "key mismatch"
+)
+
+// SchemaDifference is a structural difference between the source and
+// destination schemas. Column is empty for differences that concern a
+// whole table. Source and Destination describe each side, where relevant.
+type SchemaDifference struct {
+	Kind        SchemaDifferenceKind
+	Table       string
+	Column      string
+	Source      string
+	Destination string
+}
+
+func (d SchemaDifference) String() string {
+	subject := d.Table
+	if d.Column != "" {
+		subject = fmt.Sprintf("%s.%s", d.Table, d.Column)
+	}
+
+	switch d.Kind {
+	case MissingTable, MissingColumn:
+		return fmt.Sprintf("%s: %s is missing from MySQL", d.Kind, subject)
+	case ExtraTable, ExtraColumn:
+		return fmt.Sprintf("%s: %s only exists in MySQL", d.Kind, subject)
+	default:
+		return fmt.Sprintf("%s: %s is %s in PostgreSQL and %s in MySQL", d.Kind, subject, d.Source, d.Destination)
+	}
+}
+
+// DiffSchemas returns every structural difference between the schemas of
+// src and dst, ordered by table.
+func DiffSchemas(src, dst DB) ([]SchemaDifference, error) {
+	srcSchema, err := BuildSchema(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build source schema: %s", err)
+	}
+
+	dstSchema, err := BuildSchema(dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build destination schema: %s", err)
+	}
+
+	var differences []SchemaDifference
+	for _, name := range tableNames(srcSchema, dstSchema) {
+		srcTable, srcErr := srcSchema.GetTable(name)
+		dstTable, dstErr := dstSchema.GetTable(name)
+
+		switch {
+		case dstErr != nil:
+			differences = append(differences, SchemaDifference{Kind: MissingTable, Table: name})
+		case srcErr != nil:
+			differences = append(differences, SchemaDifference{Kind: ExtraTable, Table: name})
+		default:
+			differences = append(differences, diffTables(srcTable, dstTable)...)
+		}
+	}
+
+	return differences, nil
+}
+
+func tableNames(schemas ...*Schema) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, schema := range schemas {
+		for name := range schema.Tables {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func diffTables(src, dst *Table) []SchemaDifference {
+	var differences []SchemaDifference
+
+	if strings.Join(src.PrimaryKey, ",") != strings.Join(dst.PrimaryKey, ",") {
+		differences = append(differences, SchemaDifference{
+			Kind:        KeyMismatch,
+			Table:       src.Name,
+			Source:      describeKey(src.PrimaryKey),
+			Destination: describeKey(dst.PrimaryKey),
+		})
+	}
+
+	for _, srcColumn := range src.Columns {
+		_, dstColumn, err := dst.GetColumn(srcColumn.Name)
+		if err != nil {
+			differences = append(differences, SchemaDifference{Kind: MissingColumn, Table: src.Name, Column: srcColumn.Name})
+			continue
+		}
+
+		differences = append(differences, diffColumns(src.Name, srcColumn, dstColumn)...)
+	}
+
+	for _, dstColumn := range dst.Columns {
+		if !src.HasColumn(dstColumn.Name) {
+			differences = append(differences, SchemaDifference{Kind: ExtraColumn, Table: src.Name, Column: dstColumn.Name})
+		}
+	}
+
+	return differences
+}
+
+func describeKey(columns []string) string {
+	if len(columns) == 0 {
+		return "no key"
+	}
+	return fmt.Sprintf("(%s)", strings.Join(columns, ", "))
+}
+
+func diffColumns(table string, src, dst *Column) []SchemaDifference {
+	var differences []SchemaDifference
+	difference := func(kind SchemaDifferenceKind, source, destination string) {
+		differences = append(differences, SchemaDifference{
+			Kind:        kind,
+			Table:       table,
+			Column:      src.Name,
+			Source:      source,
+			Destination: destination,
+		})
+	}
+
+	srcFamily, dstFamily := typeFamilies[src.Type], typeFamilies[dst.Type]
+	if srcFamily != "" && dstFamily != "" && srcFamily != dstFamily {
+		difference(TypeMismatch, src.Type, dst.Type)
+	}
+
+	if src.Nullable != dst.Nullable {
+		difference(NullabilityMismatch, describeNullable(src.Nullable), describeNullable(dst.Nullable))
+	}
+
+	// serial columns become AUTO_INCREMENT, which has no default
+	if src.Default == nil || !strings.HasPrefix(*src.Default, "nextval(") {
+		srcDefault, dstDefault := normalizeDefault(src.Default), normalizeDefault(dst.Default)
+		if !equalDefaults(srcDefault, dstDefault) {
+			difference(DefaultMismatch, describeDefault(srcDefault), describeDefault(dstDefault))
+		}
+	}
+
+	return differences
+}
+
+func describeNullable(nullable bool) string {
+	if nullable {
+		return "nullable"
+	}
+	return "NOT NULL"
+}
+
+func describeDefault(def string) string {
+	if def == "" {
+		return "without a default"
+	}
+	return fmt.Sprintf("defaulted to %s", def)
+}
+
+// typeFamilies groups the data types reported by either database into
+// families of types that hold the same kind of value.
+var typeFamilies = map[string]string{
+	"smallint":  "integer",
+	"integer":   "integer",
+	"bigint":    "integer",
+	"boolean":   "integer",
+	"tinyint":   "integer",
+	"mediumint": "integer",
+	"int":       "integer",
+	"year":      "integer",
+
+	"numeric": "decimal",
+	"decimal": "decimal",
+	"money":   "decimal",
+
+	"real":             "float",
+	"double precision": "float",
+	"float":            "float",
+	"double":           "float",
+
+	"character varying": "string",
+	"character":         "string",
+	"text":              "string",
+	"uuid":              "string",
+	"inet":              "string",
+	"cidr":              "string",
+	"macaddr":           "string",
+	"interval":          "string",
+	"varchar":           "string",
+	"char":              "string",
+	"tinytext":          "string",
+	"mediumtext":        "string",
+	"longtext":          "string",
+	"enum":              "string",
+	"set":               "string",
+
+	"bytea":      "binary",
+	"binary":     "binary",
+	"varbinary":  "binary",
+	"tinyblob":   "binary",
+	"blob":       "binary",
+	"mediumblob": "binary",
+	"longblob":   "binary",
+
+	"timestamp without time zone": "datetime",
+	"timestamp with time zone":    "datetime",
+	"datetime":                    "datetime",
+	"timestamp":                   "datetime",
+
+	"date": "date",
+
+	"time without time zone": "time",
+	"time with time zone":    "time",
+	"time":                   "time",
+
+	"json":  "json",
+	"jsonb": "json",
+}
+
+var mysqlTimestampDefault = regexp.MustCompile(`(?i)^current_timestamp(\(\d*\))?$`)
+
+// normalizeDefault converts a default as reported by either database to a
+// comparable form: literals without casts or quotes, and the current time
+// as CURRENT_TIMESTAMP. It returns an empty string for no default.
+func normalizeDefault(def *string) string {
+	if def == nil || nullDefault.MatchString(*def) {
+		return ""
+	}
+
+	switch d := *def; {
+	case d == "true":
+		return "1"
+	case d == "false":
+		return "0"
+	case timestampDefault.MatchString(d), mysqlTimestampDefault.MatchString(d):
+		return "CURRENT_TIMESTAMP"
+	}
+
+	if m := stringDefault.FindStringSubmatch(*def); m != nil {
+		return strings.Replace(m[1], "''", "'", -1)
+	}
+
+	if m := numericDefault.FindStringSubmatch(*def); m != nil {
+		return m[1]
+	}
+
+	return *def
+}
+
+// equalDefaults compares normalized defaults, numerically if both are
+// numbers so that 0 and 0.00 are equal.
+func equalDefaults(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	return errA == nil && errB == nil && x == y
+}
@@ -0,0 +1,138 @@
+package pg2mysql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("DiffSchemas", func() {
+	var (
+		mysql pg2mysql.DB
+		pg    pg2mysql.DB
+	)
+
+	BeforeEach(func() {
+		mysql = pg2mysql.NewMySQLDB(
+			mysqlRunner.DBName,
+			"root",
+			"",
+			"127.0.0.1",
+			3306,
+			"",
+			"",
+			nil,
+			pg2mysql.TableFilter{},
+		)
+		err := mysql.Open()
+		Expect(err).NotTo(HaveOccurred())
+
+		pg = pg2mysql.NewPostgreSQLDB(
+			pgRunner.DBName,
+			"",
+			"",
+			"127.0.0.1",
+			5432,
+			"disable",
+			nil,
+			pg2mysql.TableFilter{},
+		)
+		err = pg.Open()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = pgRunner.DB().Exec(`
+		CREATE TABLE diff_example (
+			id integer PRIMARY KEY,
+			code varchar(20) NOT NULL,
+			price integer DEFAULT 0,
+			created_at timestamp DEFAULT now(),
+			only_pg text
+		);
+		CREATE TABLE diff_only_pg (id integer)`)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = mysqlRunner.DB().Exec(`
+		CREATE TABLE diff_example (
+			id int NOT NULL,
+			code varchar(20),
+			price varchar(10) DEFAULT '1',
+			created_at datetime DEFAULT CURRENT_TIMESTAMP,
+			only_mysql int
+		)`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_, err := pgRunner.DB().Exec("DROP TABLE diff_example; DROP TABLE diff_only_pg")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = mysqlRunner.DB().Exec("DROP TABLE diff_example")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = mysql.Close()
+		Expect(err).NotTo(HaveOccurred())
+		err = pg.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reports every difference at once", func() {
+		differences, err := pg2mysql.DiffSchemas(pg, mysql)
+		Expect(err).NotTo(HaveOccurred())
+
+		var found []pg2mysql.SchemaDifference
+		for _, difference := range differences {
+			if difference.Table == "diff_example" || difference.Table == "diff_only_pg" {
+				found = append(found, difference)
+			}
+		}
+
+		Expect(found).To(ConsistOf(
+			pg2mysql.SchemaDifference{Kind: pg2mysql.KeyMismatch, Table: "diff_example", Source: "(id)", Destination: "no key"},
+			pg2mysql.SchemaDifference{Kind: pg2mysql.NullabilityMismatch, Table: "diff_example", Column: "code", Source: "NOT NULL", Destination: "nullable"},
+			pg2mysql.SchemaDifference{Kind: pg2mysql.TypeMismatch, Table: "diff_example", Column: "price", Source: "integer", Destination: "varchar"},
+			pg2mysql.SchemaDifference{Kind: pg2mysql.DefaultMismatch, Table: "diff_example", Column: "price", Source: "defaulted to 0", Destination: "defaulted to 1"},
+			pg2mysql.SchemaDifference{Kind: pg2mysql.MissingColumn, Table: "diff_example", Column: "only_pg"},
+			pg2mysql.SchemaDifference{Kind: pg2mysql.ExtraColumn, Table: "diff_example", Column: "only_mysql"},
+			pg2mysql.SchemaDifference{Kind: pg2mysql.MissingTable, Table: "diff_only_pg"},
+		))
+	})
+
+	Context("when tables are excluded", func() {
+		var (
+			filteredMySQL pg2mysql.DB
+			filteredPG    pg2mysql.DB
+		)
+
+		BeforeEach(func() {
+			filter, err := pg2mysql.ParseTableFilter(nil, []string{"diff_*"})
+			Expect(err).NotTo(HaveOccurred())
+
+			filteredMySQL = pg2mysql.NewMySQLDB(mysqlRunner.DBName, "root", "", "127.0.0.1", 3306, "", "", nil, filter)
+			err = filteredMySQL.Open()
+			Expect(err).NotTo(HaveOccurred())
+
+			filteredPG = pg2mysql.NewPostgreSQLDB(pgRunner.DBName, "", "", "127.0.0.1", 5432, "disable", nil, filter)
+			err = filteredPG.Open()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = mysqlRunner.DB().Exec("CREATE TABLE diff_only_mysql (id int)")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, err := mysqlRunner.DB().Exec("DROP TABLE diff_only_mysql")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filteredMySQL.Close()).To(Succeed())
+			Expect(filteredPG.Close()).To(Succeed())
+		})
+
+		It("does not report differences in them on either side", func() {
+			differences, err := pg2mysql.DiffSchemas(filteredPG, filteredMySQL)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, difference := range differences {
+				Expect(difference.Table).NotTo(HavePrefix("diff_"))
+			}
+		})
+	})
+})
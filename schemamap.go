@@ -0,0 +1,151 @@
+package pg2mysql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultSchema is the PostgreSQL schema migrated when no schemas are given.
+// The names of its tables are not qualified.
+const defaultSchema = "public"
+
+// SchemaTarget says where the tables of a PostgreSQL schema are stored in
+// MySQL: in Database, with their names prefixed by TablePrefix.
+//
+// Without a Database, the tables of the public schema and of schemas with a
+// TablePrefix are stored in the database being migrated to, and the tables
+// of other schemas in the database with the same name as their schema.
+type SchemaTarget struct {
+	Database    string `yaml:"database"`
+	TablePrefix string `yaml:"table_prefix"`
+}
+
+// SchemaMap maps each PostgreSQL schema to migrate to where its tables are
+// stored in MySQL. A nil SchemaMap migrates the public schema to the
+// database being migrated to.
+type SchemaMap map[string]SchemaTarget
+
+// Schemas returns the names of the schemas to migrate, in order.
+func (m SchemaMap) Schemas() []string {
+	if len(m) == 0 {
+		return []string{defaultSchema}
+	}
+
+	var schemas []string
+	for schema := range m {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+
+	return schemas
+}
+
+// Validate returns an error if the tables of two schemas could not be told
+// apart in MySQL, given the database being migrated to: when they are
+// mapped to the same database with table prefixes of which one starts with
+// the other.
+func (m SchemaMap) Validate(database string) error {
+	schemas := m.Schemas()
+	for i, a := range schemas {
+		aDatabase, aPrefix := m.target(a, database)
+		for _, b := range schemas[i+1:] {
+			bDatabase, bPrefix := m.target(b, database)
+			if aDatabase != bDatabase {
+				continue
+			}
+
+			if strings.HasPrefix(aPrefix, bPrefix) || strings.HasPrefix(bPrefix, aPrefix) {
+				return fmt.Errorf("schemas %s and %s are both mapped to database %s with overlapping table prefixes %q and %q", a, b, aDatabase, aPrefix, bPrefix)
+			}
+		}
+	}
+
+	return nil
+}
+
+// includes reports whether the tables of schema are migrated.
+func (m SchemaMap) includes(schema string) bool {
+	if len(m) == 0 {
+		return schema == defaultSchema
+	}
+
+	_, ok := m[schema]
+	return ok
+}
+
+// target returns the MySQL database and table prefix for the tables of
+// schema, given the database being migrated to.
+func (m SchemaMap) target(schema, database string) (string, string) {
+	target := m[schema]
+	if target.Database != "" {
+		return target.Database, target.TablePrefix
+	}
+
+	if schema == defaultSchema || target.TablePrefix != "" {
+		return database, target.TablePrefix
+	}
+
+	return schema, target.TablePrefix
+}
+
+// schemaMapping is where the tables of a schema are stored in MySQL.
+type schemaMapping struct {
+	schema   string
+	database string
+	prefix   string
+}
+
+// mappings returns where the tables of each schema are stored, given the
+// database being migrated to, with longer table prefixes first.
+func (m SchemaMap) mappings(database string) []schemaMapping {
+	var mappings []schemaMapping
+	for _, schema := range m.Schemas() {
+		schemaDatabase, prefix := m.target(schema, database)
+		mappings = append(mappings, schemaMapping{schema: schema, database: schemaDatabase, prefix: prefix})
+	}
+
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return len(mappings[i].prefix) > len(mappings[j].prefix)
+	})
+
+	return mappings
+}
+
+// databases returns the MySQL databases that the schemas are mapped to,
+// given the database being migrated to, in order.
+func (m SchemaMap) databases(database string) []string {
+	seen := map[string]bool{}
+	var databases []string
+	for _, schema := range m.Schemas() {
+		schemaDatabase, _ := m.target(schema, database)
+		if !seen[schemaDatabase] {
+			seen[schemaDatabase] = true
+			databases = append(databases, schemaDatabase)
+		}
+	}
+	sort.Strings(databases)
+
+	return databases
+}
+
+// qualifiedTableName returns the name by which a table of schema is known:
+// its name, qualified by its schema unless that is the public schema. The
+// name can be used as is in PostgreSQL queries.
+func qualifiedTableName(schema, table string) string {
+	if schema == defaultSchema {
+		return table
+	}
+
+	return schema + "." + table
+}
+
+// splitTableName returns the schema and the unqualified name of a table
+// named by qualifiedTableName.
+func splitTableName(name string) (string, string) {
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+
+	return defaultSchema, name
+}
@@ -0,0 +1,54 @@
+package pg2mysql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("SchemaMap", func() {
+	Describe("Validate", func() {
+		It("accepts schemas stored in different databases or with distinct prefixes", func() {
+			schemas := pg2mysql.SchemaMap{
+				"public":  {},
+				"audit":   {},
+				"billing": {Database: "app", TablePrefix: "billing_"},
+				"sales":   {Database: "app", TablePrefix: "sales_"},
+			}
+
+			Expect(schemas.Validate("app")).To(Succeed())
+		})
+
+		It("rejects schemas stored in the same database with overlapping prefixes", func() {
+			schemas := pg2mysql.SchemaMap{
+				"public": {},
+				"audit":  {TablePrefix: "audit_"},
+			}
+
+			Expect(schemas.Validate("app")).To(MatchError(ContainSubstring("schemas audit and public")))
+		})
+
+		It("rejects schemas mapped to another database under the same prefix", func() {
+			schemas := pg2mysql.SchemaMap{
+				"audit":   {Database: "shared"},
+				"billing": {Database: "shared"},
+			}
+
+			Expect(schemas.Validate("app")).To(HaveOccurred())
+		})
+	})
+
+	Describe("Config.SchemaMap", func() {
+		It("returns an error for an ambiguous mapping", func() {
+			config := &pg2mysql.Config{}
+			config.MySQL.Database = "app"
+			config.PostgreSQL.Schemas = []string{"public", "audit"}
+			config.PostgreSQL.SchemaMapping = map[string]pg2mysql.SchemaTarget{
+				"audit": {Database: "app"},
+			}
+
+			_, err := config.SchemaMap()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
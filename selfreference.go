@@ -0,0 +1,152 @@
+package pg2mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selfReferencingColumns returns the indexes of the columns of table that
+// belong to the foreign keys by which it references itself in MySQL. Rows
+// are inserted with these columns set to NULL, and the columns are filled
+// in by updateSelfReferences once every row is present, so that foreign key
+// checks can stay enabled. It returns false if that is not possible: when
+// a column is NOT NULL in dstTable or part of the key used to find rows.
+func selfReferencingColumns(table, dstTable *Table, foreignKeys []*ForeignKeyDefinition) ([]int, bool) {
+	parts, ok := keyParts(table)
+	if !ok || dstTable == nil {
+		return nil, false
+	}
+
+	keyColumns := map[int]bool{}
+	for _, part := range parts {
+		keyColumns[part.index] = true
+	}
+
+	var columns []int
+	seen := map[int]bool{}
+	for _, fk := range foreignKeys {
+		if fk.RefTable != table.Name {
+			continue
+		}
+
+		for _, name := range fk.Columns {
+			index, _, err := table.GetColumn(name)
+			if err != nil || keyColumns[index] {
+				return nil, false
+			}
+
+			_, dstColumn, err := dstTable.GetColumn(name)
+			if err != nil || !dstColumn.Nullable {
+				return nil, false
+			}
+
+			if !seen[index] {
+				seen[index] = true
+				columns = append(columns, index)
+			}
+		}
+	}
+
+	return columns, len(columns) > 0
+}
+
+// updateSelfReferences sets the given columns of every row of table in dst
+// to their values in src. The rows are updated defaultBatchRows at a time,
+// each batch with a single statement joining table to the batch's values.
+func updateSelfReferences(src, dst DB, table *Table, columns []int) error {
+	parts, ok := keyParts(table)
+	if !ok {
+		return fmt.Errorf("table %s has no key to update rows by", table.Name)
+	}
+
+	var names, conditions, aliases, assignments, matches []string
+	for i, index := range columns {
+		name := table.Columns[index].Name
+		alias := fmt.Sprintf("c%d", i)
+		names = append(names, src.ColumnNameForSelect(name))
+		conditions = append(conditions, fmt.Sprintf("%s IS NOT NULL", src.ColumnNameForSelect(name)))
+		aliases = append(aliases, alias)
+		assignments = append(assignments, fmt.Sprintf("t.%s = v.%s", dst.ColumnNameForSelect(name), alias))
+	}
+	for i, part := range parts {
+		alias := fmt.Sprintf("k%d", i)
+		names = append(names, src.ColumnNameForSelect(part.name))
+		aliases = append(aliases, alias)
+		matches = append(matches, fmt.Sprintf("t.%s = v.%s", dst.ColumnNameForSelect(part.name), alias))
+	}
+
+	rows, err := src.Querier().Query(fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s",
+		strings.Join(names, ","),
+		src.TableNameForQuery(table.Name),
+		strings.Join(conditions, " OR "),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to select rows: %s", err)
+	}
+	defer rows.Close()
+
+	// the first row of the derived table names its columns
+	first := make([]string, len(aliases))
+	for i, alias := range aliases {
+		first[i] = "? AS " + alias
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(aliases)), ",")
+
+	// a statement holds at most 65535 placeholders
+	batchRows := defaultBatchRows
+	if limit := 65535 / len(aliases); batchRows > limit {
+		batchRows = limit
+	}
+
+	update := func(args []interface{}) error {
+		selects := []string{"SELECT " + strings.Join(first, ",")}
+		for n := len(args)/len(aliases) - 1; n > 0; n-- {
+			selects = append(selects, "SELECT "+placeholders)
+		}
+
+		_, err := dst.Querier().Exec(fmt.Sprintf(
+			"UPDATE %s t JOIN (%s) v ON %s SET %s",
+			dst.TableNameForQuery(table.Name),
+			strings.Join(selects, " UNION ALL "),
+			strings.Join(matches, " AND "),
+			strings.Join(assignments, ","),
+		), args...)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %s", table.Name, err)
+		}
+
+		return nil
+	}
+
+	values := make([]interface{}, len(names))
+	scanArgs := make([]interface{}, len(names))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	var args []interface{}
+	for rows.Next() {
+		if err = rows.Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to scan row: %s", err)
+		}
+
+		args = append(args, values...)
+		if len(args) == batchRows*len(aliases) {
+			if err = update(args); err != nil {
+				return err
+			}
+			args = args[:0]
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed iterating through rows: %s", err)
+	}
+
+	if len(args) > 0 {
+		return update(args)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package pg2mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// standaloneSequence is a PostgreSQL sequence that is not owned by any
+// column. LastValue is not valid until the sequence has generated a value.
+type standaloneSequence struct {
+	name       string
+	lastValue  sql.NullInt64
+	startValue int64
+	increment  int64
+	minValue   int64
+	maxValue   int64
+	cycles     bool
+}
+
+// readStandaloneSequences returns the sequences of the migrated schemas that
+// are not owned by a serial or identity column, named like tables. Tables
+// excluded by the table filter do not exclude sequences.
+func readStandaloneSequences(db DB) ([]standaloneSequence, error) {
+	rows, err := db.Querier().Query(`
+	SELECT ps.schemaname,
+	       ps.sequencename,
+	       ps.last_value,
+	       ps.start_value,
+	       ps.increment_by,
+	       ps.min_value,
+	       ps.max_value,
+	       ps.cycle
+	FROM   pg_sequences ps
+	       JOIN pg_namespace n
+	         ON n.nspname = ps.schemaname
+	       JOIN pg_class s
+	         ON s.relnamespace = n.oid
+	            AND s.relname = ps.sequencename
+	WHERE  ps.schemaname = ANY ($1)
+	       AND NOT EXISTS (SELECT 1
+	                       FROM   pg_depend d
+	                       WHERE  d.classid = 'pg_class'::regclass
+	                              AND d.objid = s.oid
+	                              AND d.refclassid = 'pg_class'::regclass
+	                              AND d.deptype IN ('a', 'i'))
+	ORDER  BY ps.schemaname,
+	          ps.sequencename`, pq.Array(db.Schemas()))
+	if err != nil {
+		return nil, err
+	}
+
+	var sequences []standaloneSequence
+	for rows.Next() {
+		var schema, name string
+		var s standaloneSequence
+		if err := rows.Scan(&schema, &name, &s.lastValue, &s.startValue, &s.increment, &s.minValue, &s.maxValue, &s.cycles); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		s.name = qualifiedTableName(schema, name)
+		sequences = append(sequences, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sequences, rows.Close()
+}
+
+// migrateSequences copies the state of every standalone sequence into
+// table, a MySQL table with one row per sequence that is created if it does
+// not exist. Sequences already in table are overwritten.
+func migrateSequences(src, dst DB, table string, watcher MigratorWatcher) error {
+	sequences, err := readStandaloneSequences(src)
+	if err != nil {
+		return fmt.Errorf("failed to read sequences: %s", err)
+	}
+
+	// names are qualified by their schema outside of public, and both parts
+	// may be up to 63 characters long
+	_, err = dst.Querier().Exec(fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %s (
+		name         varchar(127) NOT NULL PRIMARY KEY,
+		last_value   bigint,
+		start_value  bigint NOT NULL,
+		increment_by bigint NOT NULL,
+		min_value    bigint NOT NULL,
+		max_value    bigint NOT NULL,
+		cycles       boolean NOT NULL
+	)`, table))
+	if err != nil {
+		return fmt.Errorf("failed to create sequence table: %s", err)
+	}
+
+	stmt := fmt.Sprintf(`
+	INSERT INTO %s (name, last_value, start_value, increment_by, min_value, max_value, cycles)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE last_value = VALUES(last_value),
+	                        start_value = VALUES(start_value),
+	                        increment_by = VALUES(increment_by),
+	                        min_value = VALUES(min_value),
+	                        max_value = VALUES(max_value),
+	                        cycles = VALUES(cycles)`, table)
+
+	for _, s := range sequences {
+		_, err := dst.Querier().Exec(stmt, s.name, s.lastValue, s.startValue, s.increment, s.minValue, s.maxValue, s.cycles)
+		if err != nil {
+			return fmt.Errorf("failed to migrate sequence %s: %s", s.name, err)
+		}
+
+		watcher.DidMigrateSequence(s.name)
+	}
+
+	return nil
+}
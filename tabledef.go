@@ -0,0 +1,517 @@
+package pg2mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// TableDefinition describes a PostgreSQL table in enough detail to create
+// an equivalent table in MySQL.
+type TableDefinition struct {
+	// Name is the name of the table, qualified by its schema unless that
+	// is the public schema. Schema is the owning schema.
+	Name        string
+	Schema      string
+	Comment     string
+	Columns     []*ColumnDefinition
+	PrimaryKey  []string
+	Indexes     []*IndexDefinition
+	ForeignKeys []*ForeignKeyDefinition
+}
+
+// GetColumn returns the column with the given name, or nil.
+func (t *TableDefinition) GetColumn(name string) *ColumnDefinition {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+
+	return nil
+}
+
+type ColumnDefinition struct {
+	Name string
+
+	// DataType is the type as reported by information_schema, e.g.
+	// "character varying", and UDTName the underlying type name, e.g.
+	// "varchar", which identifies arrays, enums and extension types.
+	DataType string
+	UDTName  string
+
+	MaxChars          int64
+	NumericPrecision  int64
+	NumericScale      int64
+	DatetimePrecision int64
+
+	Nullable bool
+	Default  *string
+	Identity bool
+	Comment  string
+
+	// EnumValues holds the labels of an enum type, in order.
+	EnumValues []string
+
+	// ObservedMaxChars and ObservedMaxBytes hold the longest value found in
+	// the column, when it has been sized from the data.
+	ObservedMaxChars *int64
+	ObservedMaxBytes *int64
+}
+
+// IndexDefinition describes a secondary index. Partial and expression
+// indexes cannot be created in MySQL and are recorded as unsupported.
+type IndexDefinition struct {
+	Name        string
+	Unique      bool
+	Columns     []string
+	Unsupported bool
+}
+
+type ForeignKeyDefinition struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+
+	// refCollations holds the MySQL collation of each referenced column,
+	// or "" for columns without one. It is only set for foreign keys read
+	// from MySQL.
+	refCollations []string
+}
+
+// ReadTableDefinitions reads the definition of every migrated table from the
+// PostgreSQL catalogs.
+func ReadTableDefinitions(db DB) ([]*TableDefinition, error) {
+	tables, err := readTables(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables: %s", err)
+	}
+
+	byName := map[string]*TableDefinition{}
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	enums, err := readEnums(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enums: %s", err)
+	}
+
+	if err := readColumns(db, byName, enums); err != nil {
+		return nil, fmt.Errorf("failed to read columns: %s", err)
+	}
+
+	if err := readIndexes(db, byName); err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %s", err)
+	}
+
+	if err := readForeignKeys(db, byName); err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %s", err)
+	}
+
+	return tables, nil
+}
+
+func readTables(db DB) ([]*TableDefinition, error) {
+	rows, err := db.Querier().Query(`
+	SELECT n.nspname,
+	       c.relname,
+	       coalesce(obj_description(c.oid, 'pg_class'), '')
+	FROM   pg_class c
+	       JOIN pg_namespace n
+	         ON n.oid = c.relnamespace
+	WHERE  n.nspname = ANY ($1)
+	       AND c.relkind IN ('r', 'p')
+	ORDER  BY n.nspname,
+	          c.relname`, pq.Array(db.Schemas()))
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*TableDefinition
+	for rows.Next() {
+		var tableName string
+		table := &TableDefinition{}
+		if err := rows.Scan(&table.Schema, &tableName, &table.Comment); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		name, ok := db.TableName(table.Schema, tableName)
+		if !ok {
+			continue
+		}
+		table.Name = name
+
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tables, rows.Close()
+}
+
+// readEnums returns the labels of the enum types used by the columns of
+// the tables of the migrated schemas, by the qualified name of each type.
+// The types themselves may be defined in any schema.
+func readEnums(db DB) (map[string][]string, error) {
+	rows, err := db.Querier().Query(`
+	SELECT tn.nspname,
+	       t.typname,
+	       e.enumlabel
+	FROM   pg_enum e
+	       JOIN pg_type t
+	         ON t.oid = e.enumtypid
+	       JOIN pg_namespace tn
+	         ON tn.oid = t.typnamespace
+	WHERE  t.oid IN (SELECT a.atttypid
+	                 FROM   pg_attribute a
+	                        JOIN pg_class c
+	                          ON c.oid = a.attrelid
+	                        JOIN pg_namespace n
+	                          ON n.oid = c.relnamespace
+	                 WHERE  n.nspname = ANY ($1))
+	ORDER  BY tn.nspname,
+	          t.typname,
+	          e.enumsortorder`, pq.Array(db.Schemas()))
+	if err != nil {
+		return nil, err
+	}
+
+	enums := map[string][]string{}
+	for rows.Next() {
+		var schema, name, label string
+		if err := rows.Scan(&schema, &name, &label); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		key := schema + "." + name
+		enums[key] = append(enums[key], label)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return enums, rows.Close()
+}
+
+func readColumns(db DB, tables map[string]*TableDefinition, enums map[string][]string) error {
+	rows, err := db.Querier().Query(`
+	SELECT c.table_schema,
+	       c.table_name,
+	       c.column_name,
+	       c.data_type,
+	       c.udt_schema,
+	       c.udt_name,
+	       c.character_maximum_length,
+	       c.numeric_precision,
+	       c.numeric_scale,
+	       c.datetime_precision,
+	       c.is_nullable = 'YES',
+	       c.column_default,
+	       c.is_identity = 'YES',
+	       coalesce(col_description(( quote_ident(c.table_schema)
+	                                   || '.'
+	                                   || quote_ident(c.table_name) )::regclass, c.ordinal_position), '')
+	FROM   information_schema.columns c
+	WHERE  c.table_schema = ANY ($1)
+	ORDER  BY c.table_schema,
+	          c.table_name,
+	          c.ordinal_position`, pq.Array(db.Schemas()))
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var (
+			tableSchema       string
+			tableName         string
+			udtSchema         string
+			column            ColumnDefinition
+			maxChars          sql.NullInt64
+			numericPrecision  sql.NullInt64
+			numericScale      sql.NullInt64
+			datetimePrecision sql.NullInt64
+			columnDefault     sql.NullString
+		)
+
+		err := rows.Scan(
+			&tableSchema,
+			&tableName,
+			&column.Name,
+			&column.DataType,
+			&udtSchema,
+			&column.UDTName,
+			&maxChars,
+			&numericPrecision,
+			&numericScale,
+			&datetimePrecision,
+			&column.Nullable,
+			&columnDefault,
+			&column.Identity,
+			&column.Comment,
+		)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		name, ok := db.TableName(tableSchema, tableName)
+		if !ok {
+			continue
+		}
+
+		table, ok := tables[name]
+		if !ok {
+			continue
+		}
+
+		column.MaxChars = maxChars.Int64
+		column.NumericPrecision = numericPrecision.Int64
+		column.NumericScale = numericScale.Int64
+		column.DatetimePrecision = datetimePrecision.Int64
+		if columnDefault.Valid {
+			column.Default = &columnDefault.String
+		}
+		column.EnumValues = enums[udtSchema+"."+column.UDTName]
+
+		table.Columns = append(table.Columns, &column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+func readIndexes(db DB, tables map[string]*TableDefinition) error {
+	rows, err := db.Querier().Query(`
+	SELECT n.nspname,
+	       t.relname,
+	       i.relname,
+	       x.indisprimary,
+	       x.indisunique,
+	       x.indpred IS NOT NULL
+	       OR x.indexprs IS NOT NULL,
+	       coalesce(a.attname, '')
+	FROM   pg_index x
+	       JOIN pg_class t
+	         ON t.oid = x.indrelid
+	       JOIN pg_class i
+	         ON i.oid = x.indexrelid
+	       JOIN pg_namespace n
+	         ON n.oid = t.relnamespace
+	       CROSS JOIN LATERAL generate_subscripts(x.indkey::int2[], 1) AS k(n)
+	       LEFT JOIN pg_attribute a
+	              ON a.attrelid = t.oid
+	                 AND a.attnum = (x.indkey::int2[])[k.n]
+	WHERE  n.nspname = ANY ($1)
+	ORDER  BY n.nspname,
+	          t.relname,
+	          i.relname,
+	          k.n`, pq.Array(db.Schemas()))
+	if err != nil {
+		return err
+	}
+
+	var index *IndexDefinition
+	var indexTable string
+	for rows.Next() {
+		var (
+			tableSchema, tableName, indexName, column string
+			primary, unique, unsupported              bool
+		)
+
+		if err := rows.Scan(&tableSchema, &tableName, &indexName, &primary, &unique, &unsupported, &column); err != nil {
+			rows.Close()
+			return err
+		}
+
+		name, ok := db.TableName(tableSchema, tableName)
+		if !ok {
+			continue
+		}
+
+		table, ok := tables[name]
+		if !ok {
+			continue
+		}
+
+		if primary {
+			table.PrimaryKey = append(table.PrimaryKey, column)
+			continue
+		}
+
+		// index names are only unique within a schema
+		if index == nil || index.Name != indexName || indexTable != name {
+			indexTable = name
+			index = &IndexDefinition{
+				Name:        indexName,
+				Unique:      unique,
+				Unsupported: unsupported,
+			}
+			table.Indexes = append(table.Indexes, index)
+		}
+
+		index.Columns = append(index.Columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+var foreignKeyActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+func readForeignKeys(db DB, tables map[string]*TableDefinition) error {
+	rows, err := db.Querier().Query(`
+	SELECT n.nspname,
+	       t.relname,
+	       con.conname,
+	       a.attname,
+	       rn.nspname,
+	       rt.relname,
+	       ra.attname,
+	       con.confupdtype,
+	       con.confdeltype
+	FROM   pg_constraint con
+	       JOIN pg_class t
+	         ON t.oid = con.conrelid
+	       JOIN pg_namespace n
+	         ON n.oid = t.relnamespace
+	       JOIN pg_class rt
+	         ON rt.oid = con.confrelid
+	       JOIN pg_namespace rn
+	         ON rn.oid = rt.relnamespace
+	       CROSS JOIN LATERAL generate_subscripts(con.conkey, 1) AS k(n)
+	       JOIN pg_attribute a
+	         ON a.attrelid = con.conrelid
+	            AND a.attnum = con.conkey[k.n]
+	       JOIN pg_attribute ra
+	         ON ra.attrelid = con.confrelid
+	            AND ra.attnum = con.confkey[k.n]
+	WHERE  con.contype = 'f'
+	       AND n.nspname = ANY ($1)
+	ORDER  BY n.nspname,
+	          t.relname,
+	          con.conname,
+	          k.n`, pq.Array(db.Schemas()))
+	if err != nil {
+		return err
+	}
+
+	var fk *ForeignKeyDefinition
+	var fkTable string
+	for rows.Next() {
+		var tableSchema, tableName, name, column, refSchema, refTableName, refColumn, onUpdate, onDelete string
+		err := rows.Scan(&tableSchema, &tableName, &name, &column, &refSchema, &refTableName, &refColumn, &onUpdate, &onDelete)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		qualifiedName, ok := db.TableName(tableSchema, tableName)
+		if !ok {
+			continue
+		}
+
+		table, ok := tables[qualifiedName]
+		if !ok {
+			continue
+		}
+
+		// constraint names are only unique within a table
+		if fk == nil || fk.Name != name || fkTable != qualifiedName {
+			fkTable = qualifiedName
+			fk = &ForeignKeyDefinition{
+				Name:     name,
+				RefTable: qualifiedTableName(refSchema, refTableName),
+				OnUpdate: foreignKeyActions[onUpdate],
+				OnDelete: foreignKeyActions[onDelete],
+			}
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		}
+
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// sizedTypes are the source types that SizeColumns measures.
+var sizedTypes = map[string]bool{
+	"character varying": true,
+	"character":         true,
+	"text":              true,
+	"bytea":             true,
+	"citext":            true,
+}
+
+// SizeColumns records the longest value, in characters and in bytes, of
+// every character and binary column of tables.
+func SizeColumns(db DB, tables []*TableDefinition) error {
+	for _, table := range tables {
+		var columns []*ColumnDefinition
+		var exprs []string
+		for _, column := range table.Columns {
+			if !sizedTypes[column.DataType] && !sizedTypes[column.UDTName] {
+				continue
+			}
+
+			lengthFunc := "char_length"
+			if column.DataType == "bytea" {
+				lengthFunc = "octet_length"
+			}
+
+			columns = append(columns, column)
+			exprs = append(exprs,
+				fmt.Sprintf("coalesce(max(%s(%s)), 0)", lengthFunc, column.Name),
+				fmt.Sprintf("coalesce(max(octet_length(%s)), 0)", column.Name),
+			)
+		}
+
+		if len(columns) == 0 {
+			continue
+		}
+
+		values := make([]int64, len(exprs))
+		scanArgs := make([]interface{}, len(exprs))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+
+		stmt := fmt.Sprintf("SELECT %s FROM %s", strings.Join(exprs, ","), table.Name)
+		if err := db.Querier().QueryRow(stmt).Scan(scanArgs...); err != nil {
+			return fmt.Errorf("failed to size columns of %s: %s", table.Name, err)
+		}
+
+		for i, column := range columns {
+			column.ObservedMaxChars = &values[2*i]
+			column.ObservedMaxBytes = &values[2*i+1]
+		}
+	}
+
+	return nil
+}
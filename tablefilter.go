@@ -0,0 +1,46 @@
+package pg2mysql
+
+import (
+	"fmt"
+	"path"
+)
+
+// TableFilter selects the tables to migrate by matching their qualified
+// names, e.g. "users" or "billing.invoices", against glob patterns as
+// understood by path.Match. The zero TableFilter selects every table.
+type TableFilter struct {
+	include []string
+	exclude []string
+}
+
+// ParseTableFilter returns a filter selecting the tables that match one of
+// include, or every table if it is empty, and none of exclude.
+func ParseTableFilter(include, exclude []string) (TableFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return TableFilter{}, fmt.Errorf("invalid table pattern %q: %s", pattern, err)
+		}
+	}
+
+	return TableFilter{include: include, exclude: exclude}, nil
+}
+
+// Match reports whether the table with the given qualified name is
+// selected.
+func (f TableFilter) Match(name string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return false
+	}
+
+	return !matchAny(f.exclude, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,29 @@
+package pg2mysql_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/pg2mysql"
+)
+
+var _ = Describe("TableFilter", func() {
+	It("selects every table by default", func() {
+		Expect(pg2mysql.TableFilter{}.Match("users")).To(BeTrue())
+		Expect(pg2mysql.TableFilter{}.Match("billing.invoices")).To(BeTrue())
+	})
+
+	It("selects the tables that match an include pattern and no exclude pattern", func() {
+		filter, err := pg2mysql.ParseTableFilter([]string{"app_*", "billing.*"}, []string{"app_audit_*"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filter.Match("app_users")).To(BeTrue())
+		Expect(filter.Match("billing.invoices")).To(BeTrue())
+		Expect(filter.Match("app_audit_events")).To(BeFalse())
+		Expect(filter.Match("schema_migrations")).To(BeFalse())
+	})
+
+	It("rejects invalid patterns", func() {
+		_, err := pg2mysql.ParseTableFilter(nil, []string{"app_["})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,148 @@
+package pg2mysql
+
+import "sort"
+
+// tableOrder is the order in which the tables of a schema can be migrated
+// with foreign key checks enabled.
+type tableOrder struct {
+	// levels groups the tables so that every table comes after the tables
+	// it references. The tables of a level do not reference each other and
+	// may be migrated concurrently.
+	levels [][]*Table
+
+	// cycles holds the groups of tables whose foreign keys reference each
+	// other, directly or through other tables. Their rows can only be
+	// migrated with foreign key checks disabled. Each group shares a level.
+	cycles [][]string
+
+	// selfReferencing holds the tables with a foreign key referencing the
+	// table itself.
+	selfReferencing []string
+}
+
+// orderTables orders the tables of schema by the foreign keys between them.
+// Foreign keys referencing tables outside of schema are ignored.
+func orderTables(schema *Schema, foreignKeys map[string][]*ForeignKeyDefinition) *tableOrder {
+	var names []string
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	references := map[string][]string{}
+	selfReferencing := map[string]bool{}
+	for _, name := range names {
+		for _, fk := range foreignKeys[name] {
+			if _, ok := schema.Tables[fk.RefTable]; !ok {
+				continue
+			}
+
+			if fk.RefTable == name {
+				selfReferencing[name] = true
+				continue
+			}
+
+			references[name] = append(references[name], fk.RefTable)
+		}
+	}
+
+	components, componentOf := stronglyConnectedComponents(names, references)
+
+	// each component comes one level after the deepest component it
+	// references; components are found referenced tables first
+	order := &tableOrder{}
+	depth := make([]int, len(components))
+	for i, component := range components {
+		for _, name := range component {
+			for _, ref := range references[name] {
+				if j := componentOf[ref]; j != i && depth[j]+1 > depth[i] {
+					depth[i] = depth[j] + 1
+				}
+			}
+		}
+
+		for depth[i] >= len(order.levels) {
+			order.levels = append(order.levels, nil)
+		}
+
+		sort.Strings(component)
+		for _, name := range component {
+			order.levels[depth[i]] = append(order.levels[depth[i]], schema.Tables[name])
+		}
+
+		if len(component) > 1 {
+			order.cycles = append(order.cycles, component)
+		}
+	}
+
+	for _, name := range names {
+		if selfReferencing[name] {
+			order.selfReferencing = append(order.selfReferencing, name)
+		}
+	}
+
+	for _, level := range order.levels {
+		sort.Slice(level, func(i, j int) bool { return level[i].Name < level[j].Name })
+	}
+
+	return order
+}
+
+// stronglyConnectedComponents returns the strongly connected components of
+// the graph of references between names, using Tarjan's algorithm, along
+// with the index of the component of each name. A component is returned
+// after every component it references.
+func stronglyConnectedComponents(names []string, references map[string][]string) ([][]string, map[string]int) {
+	var (
+		components  [][]string
+		componentOf = map[string]int{}
+		index       = map[string]int{}
+		lowLink     = map[string]int{}
+		onStack     = map[string]bool{}
+		stack       []string
+	)
+
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, ref := range references[name] {
+			if _, visited := index[ref]; !visited {
+				visit(ref)
+				if lowLink[ref] < lowLink[name] {
+					lowLink[name] = lowLink[ref]
+				}
+			} else if onStack[ref] && index[ref] < lowLink[name] {
+				lowLink[name] = index[ref]
+			}
+		}
+
+		if lowLink[name] != index[name] {
+			return
+		}
+
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			componentOf[top] = len(components)
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+
+	for _, name := range names {
+		if _, visited := index[name]; !visited {
+			visit(name)
+		}
+	}
+
+	return components, componentOf
+}
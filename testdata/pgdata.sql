@@ -6,12 +6,14 @@ CREATE TABLE IF NOT EXISTS table_with_id (
   null_name text,
   ci_name citext NOT NULL,
   created_at timestamp without time zone DEFAULT now() NOT NULL,
-  truthiness bool NOT NULL
+  truthiness bool NOT NULL,
+  PRIMARY KEY (id)
 );
 
 CREATE TABLE IF NOT EXISTS table_with_string_id (
   id varchar(36) NOT NULL,
-  name varchar(255) NOT NULL
+  name varchar(255) NOT NULL,
+  PRIMARY KEY (id)
 );
 
 CREATE TABLE IF NOT EXISTS table_without_id (
@@ -30,6 +30,16 @@ func (v *validator) Validate() ([]ValidationResult, error) {
 		return nil, fmt.Errorf("failed to build destination schema: %s", err)
 	}
 
+	uniqueIndexes, err := readUniqueIndexes(v.dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination unique indexes: %s", err)
+	}
+
+	foreignKeys, err := readMySQLForeignKeys(v.dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination foreign keys: %s", err)
+	}
+
 	var results []ValidationResult
 	for _, srcTable := range srcSchema.Tables {
 		dstTable, err := dstSchema.GetTable(srcTable.Name)
@@ -37,7 +47,22 @@ func (v *validator) Validate() ([]ValidationResult, error) {
 			return nil, fmt.Errorf("failed to get table from destination schema: %s", err)
 		}
 
-		if srcTable.HasColumn("id") {
+		invalidColumns, err := GetInvalidColumns(v.src, srcTable, dstTable)
+		if err != nil {
+			return nil, fmt.Errorf("failed checking column values: %s", err)
+		}
+
+		collisions, err := getUniqueCollisions(v.src, srcTable, uniqueIndexes[srcTable.Name])
+		if err != nil {
+			return nil, fmt.Errorf("failed checking unique indexes: %s", err)
+		}
+
+		violations, err := getForeignKeyViolations(v.src, srcSchema, srcTable, foreignKeys[srcTable.Name])
+		if err != nil {
+			return nil, fmt.Errorf("failed checking foreign keys: %s", err)
+		}
+
+		if srcTable.HasKey() {
 			rowIDs, err := GetIncompatibleRowIDs(v.src, srcTable, dstTable)
 			if err != nil {
 				return nil, fmt.Errorf("failed getting incompatible row ids: %s", err)
@@ -47,6 +72,10 @@ func (v *validator) Validate() ([]ValidationResult, error) {
 				TableName:            srcTable.Name,
 				IncompatibleRowIDs:   rowIDs,
 				IncompatibleRowCount: int64(len(rowIDs)),
+				UnsafeColumns:        GetUnsafeColumns(srcTable, dstTable),
+				InvalidColumns:       invalidColumns,
+				UniqueCollisions:     collisions,
+				ForeignKeyViolations: violations,
 			})
 		} else {
 			rowCount, err := GetIncompatibleRowCount(v.src, srcTable, dstTable)
@@ -57,6 +86,10 @@ func (v *validator) Validate() ([]ValidationResult, error) {
 			results = append(results, ValidationResult{
 				TableName:            srcTable.Name,
 				IncompatibleRowCount: rowCount,
+				UnsafeColumns:        GetUnsafeColumns(srcTable, dstTable),
+				InvalidColumns:       invalidColumns,
+				UniqueCollisions:     collisions,
+				ForeignKeyViolations: violations,
 			})
 		}
 	}
@@ -66,6 +99,22 @@ func (v *validator) Validate() ([]ValidationResult, error) {
 
 type ValidationResult struct {
 	TableName            string
-	IncompatibleRowIDs   []int
+	IncompatibleRowIDs   []RowKey
 	IncompatibleRowCount int64
+
+	// UnsafeColumns lists the columns whose MySQL types cannot hold every
+	// value of their PostgreSQL types exactly.
+	UnsafeColumns []ColumnConversion
+
+	// InvalidColumns lists the columns with values that cannot be stored
+	// in MySQL as they are.
+	InvalidColumns []InvalidColumn
+
+	// UniqueCollisions lists the groups of rows that would be duplicates
+	// under the collations of a unique index in MySQL.
+	UniqueCollisions []UniqueCollision
+
+	// ForeignKeyViolations lists the rows that reference missing rows
+	// through a foreign key defined in MySQL.
+	ForeignKeyViolations []ForeignKeyViolation
 }
@@ -13,6 +13,14 @@ var _ = Describe("Validator", func() {
 		pg        pg2mysql.DB
 	)
 
+	// MySQL timestamps in the test schema have no fractional seconds
+	createdAtIsLossy := []pg2mysql.ColumnConversion{{
+		Column:          "created_at",
+		SourceType:      "timestamp(6) without time zone",
+		DestinationType: "timestamp",
+		Compatibility:   pg2mysql.Lossy,
+	}}
+
 	BeforeEach(func() {
 		mysql = pg2mysql.NewMySQLDB(
 			mysqlRunner.DBName,
@@ -20,6 +28,10 @@ var _ = Describe("Validator", func() {
 			"",
 			"127.0.0.1",
 			3306,
+			"",
+			"",
+			nil,
+			pg2mysql.TableFilter{},
 		)
 
 		err := mysql.Open()
@@ -32,6 +44,8 @@ var _ = Describe("Validator", func() {
 			"127.0.0.1",
 			5432,
 			"disable",
+			nil,
+			pg2mysql.TableFilter{},
 		)
 		err = pg.Open()
 		Expect(err).NotTo(HaveOccurred())
@@ -52,11 +66,13 @@ var _ = Describe("Validator", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(HaveLen(3))
 			Expect(result).To(ContainElement(pg2mysql.ValidationResult{
-				TableName: "table_with_id",
+				TableName:     "table_with_id",
+				UnsafeColumns: createdAtIsLossy,
 			}))
 
 			Expect(result).To(ContainElement(pg2mysql.ValidationResult{
-				TableName: "table_without_id",
+				TableName:     "table_without_id",
+				UnsafeColumns: createdAtIsLossy,
 			}))
 		})
 
@@ -74,11 +90,13 @@ var _ = Describe("Validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(3))
 				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
-					TableName: "table_with_id",
+					TableName:     "table_with_id",
+					UnsafeColumns: createdAtIsLossy,
 				}))
 
 				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
-					TableName: "table_without_id",
+					TableName:     "table_without_id",
+					UnsafeColumns: createdAtIsLossy,
 				}))
 			})
 		})
@@ -99,12 +117,406 @@ var _ = Describe("Validator", func() {
 				Expect(result).To(HaveLen(3))
 				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
 					TableName:            "table_with_id",
-					IncompatibleRowIDs:   []int{3},
+					IncompatibleRowIDs:   []pg2mysql.RowKey{{"3"}},
+					IncompatibleRowCount: 1,
+					UnsafeColumns:        createdAtIsLossy,
+				}))
+
+				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
+					TableName:     "table_without_id",
+					UnsafeColumns: createdAtIsLossy,
+				}))
+			})
+		})
+
+		Context("when there is incompatible data in postgres in a table with a composite key", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_composite_key (space_id integer NOT NULL, user_guid varchar(36) NOT NULL, name text NOT NULL, PRIMARY KEY (space_id, user_guid))")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_composite_key (space_id integer NOT NULL, user_guid varchar(36) NOT NULL, name varchar(10) NOT NULL, PRIMARY KEY (space_id, user_guid))")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_composite_key VALUES (1, 'a', 'short'), (1, 'b', 'much-too-long')")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_composite_key")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_composite_key")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the keys of the incompatible rows", func() {
+				result, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
+					TableName:            "table_with_composite_key",
+					IncompatibleRowIDs:   []pg2mysql.RowKey{{"1", "b"}},
 					IncompatibleRowCount: 1,
 				}))
+			})
+		})
+
+		Context("when the MySQL column types are narrower than the PostgreSQL types", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_narrow_types (id bigint PRIMARY KEY, amount numeric(20,4), happened_at timestamptz)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_narrow_types (id int PRIMARY KEY, amount decimal(10,2), happened_at date)")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_narrow_types")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_narrow_types")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the unsafe columns", func() {
+				results, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var result pg2mysql.ValidationResult
+				for _, r := range results {
+					if r.TableName == "table_with_narrow_types" {
+						result = r
+					}
+				}
+
+				Expect(result.UnsafeColumns).To(ConsistOf(
+					pg2mysql.ColumnConversion{Column: "id", SourceType: "bigint", DestinationType: "int", Compatibility: pg2mysql.Incompatible},
+					pg2mysql.ColumnConversion{Column: "amount", SourceType: "numeric(20,4)", DestinationType: "decimal(10,2)", Compatibility: pg2mysql.Incompatible},
+					pg2mysql.ColumnConversion{Column: "happened_at", SourceType: "timestamp(6) with time zone", DestinationType: "date", Compatibility: pg2mysql.Lossy},
+				))
+			})
+
+			Context("when there are values that do not fit", func() {
+				BeforeEach(func() {
+					_, err := pgRunner.DB().Exec("INSERT INTO table_with_narrow_types (id, amount) VALUES (1, 12345.6789), (2, 123456789.5), (3000000000, 1)")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("reports the rows for each column", func() {
+					results, err := validator.Validate()
+					Expect(err).NotTo(HaveOccurred())
+
+					var result pg2mysql.ValidationResult
+					for _, r := range results {
+						if r.TableName == "table_with_narrow_types" {
+							result = r
+						}
+					}
+
+					Expect(result.InvalidColumns).To(ConsistOf(
+						pg2mysql.InvalidColumn{Column: "id", Reason: "has values out of range for int", RowIDs: []pg2mysql.RowKey{{"3000000000"}}, RowCount: 1},
+						pg2mysql.InvalidColumn{Column: "amount", Reason: "has values out of range for decimal(10,2)", RowIDs: []pg2mysql.RowKey{{"2"}}, RowCount: 1},
+						pg2mysql.InvalidColumn{Column: "amount", Reason: "has values that would lose precision in decimal(10,2)", RowIDs: []pg2mysql.RowKey{{"1"}}, RowCount: 1},
+					))
+				})
+			})
+		})
+
+		Context("when there are dates and times MySQL cannot store", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_dates (id integer PRIMARY KEY, happened_at timestamp(0), born_on date)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_dates (id integer PRIMARY KEY, happened_at timestamp NULL, born_on date)")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec(`INSERT INTO table_with_dates VALUES
+					(1, 'infinity', '2000-01-01'),
+					(2, '1960-01-01 00:00:00', '0044-03-15 BC'),
+					(3, '2020-01-01 00:00:00', '10000-01-01')`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_dates")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_dates")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the rows for each column", func() {
+				results, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var result pg2mysql.ValidationResult
+				for _, r := range results {
+					if r.TableName == "table_with_dates" {
+						result = r
+					}
+				}
+
+				Expect(result.InvalidColumns).To(ConsistOf(
+					pg2mysql.InvalidColumn{Column: "happened_at", Reason: "has infinite values", RowIDs: []pg2mysql.RowKey{{"1"}}, RowCount: 1},
+					pg2mysql.InvalidColumn{Column: "happened_at", Reason: "has values out of range for timestamp", RowIDs: []pg2mysql.RowKey{{"2"}}, RowCount: 1},
+					pg2mysql.InvalidColumn{Column: "born_on", Reason: "has values out of range for date", RowIDs: []pg2mysql.RowKey{{"2"}, {"3"}}, RowCount: 2},
+				))
+			})
+		})
+
+		Context("when a nullable column is NOT NULL in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_nulls (id integer PRIMARY KEY, label text); CREATE TABLE keyless_table_with_nulls (label text)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_nulls (id integer PRIMARY KEY, label varchar(255) NOT NULL)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE keyless_table_with_nulls (label varchar(255) NOT NULL)")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_nulls VALUES (1, 'a'), (2, NULL), (3, NULL); INSERT INTO keyless_table_with_nulls VALUES ('a'), (NULL)")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_nulls; DROP TABLE keyless_table_with_nulls")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_nulls")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE keyless_table_with_nulls")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the rows holding NULLs", func() {
+				result, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
+					TableName: "table_with_nulls",
+					InvalidColumns: []pg2mysql.InvalidColumn{{
+						Column:   "label",
+						Reason:   "has NULL values, but is NOT NULL in MySQL",
+						RowIDs:   []pg2mysql.RowKey{{"2"}, {"3"}},
+						RowCount: 2,
+					}},
+				}))
+				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
+					TableName: "keyless_table_with_nulls",
+					InvalidColumns: []pg2mysql.InvalidColumn{{
+						Column:   "label",
+						Reason:   "has NULL values, but is NOT NULL in MySQL",
+						RowCount: 1,
+					}},
+				}))
+			})
+		})
+
+		Context("when MySQL columns use character sets that cannot represent every character", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_charsets (id integer PRIMARY KEY, comment text, name text)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_charsets (id integer PRIMARY KEY, comment varchar(255) CHARACTER SET utf8, name varchar(255) CHARACTER SET latin1)")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_charsets VALUES (1, 'plain', 'café'), (2, 'smile 😀', 'naïve – “quoted”'), (3, '日本', '日本')")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_charsets")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_charsets")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the rows that cannot be represented", func() {
+				results, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+
+				invalid := map[string]pg2mysql.InvalidColumn{}
+				for _, result := range results {
+					if result.TableName == "table_with_charsets" {
+						for _, column := range result.InvalidColumns {
+							invalid[column.Column] = column
+						}
+					}
+				}
+
+				Expect(invalid).To(HaveLen(2))
+
+				// newer servers report utf8 as utf8mb3
+				Expect(invalid["comment"].Reason).To(MatchRegexp(`^has characters that utf8(mb3)? cannot represent$`))
+				Expect(invalid["comment"].RowIDs).To(Equal([]pg2mysql.RowKey{{"2"}}))
+
+				Expect(invalid["name"].Reason).To(Equal("has characters that latin1 cannot represent"))
+				Expect(invalid["name"].RowIDs).To(Equal([]pg2mysql.RowKey{{"3"}}))
+			})
+		})
+
+		Context("when multi-byte text is longer than a MySQL text column can hold", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_long_text (id integer PRIMARY KEY, body text)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_long_text (id integer PRIMARY KEY, body text CHARACTER SET utf8mb4)")
+				Expect(err).NotTo(HaveOccurred())
+
+				// both are shorter than 65535 characters, but only the first
+				// fits in 65535 bytes
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_long_text VALUES (1, repeat('a', 60000)), (2, repeat('日', 30000))")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_long_text")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_long_text")
+				Expect(err).NotTo(HaveOccurred())
+			})
 
+			It("compares the length in bytes", func() {
+				result, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
-					TableName: "table_without_id",
+					TableName:            "table_with_long_text",
+					IncompatibleRowIDs:   []pg2mysql.RowKey{{"2"}},
+					IncompatibleRowCount: 1,
+				}))
+			})
+		})
+
+		Context("when distinct values would collide in a unique index in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_unique_names (id integer PRIMARY KEY, name text UNIQUE, code text UNIQUE)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(`CREATE TABLE table_with_unique_names (
+					id integer PRIMARY KEY,
+					name varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci UNIQUE,
+					code varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin,
+					UNIQUE KEY code_key (code)
+				)`)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_unique_names VALUES (1, 'Foo', 'a'), (2, 'foo', 'a '), (3, 'Café', 'c'), (4, 'cafe', 'C'), (5, NULL, NULL), (6, NULL, NULL)")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_unique_names")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_unique_names")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the rows that would collide under each collation", func() {
+				results, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var result pg2mysql.ValidationResult
+				for _, r := range results {
+					if r.TableName == "table_with_unique_names" {
+						result = r
+					}
+				}
+
+				Expect(result.UniqueCollisions).To(ConsistOf(
+					pg2mysql.UniqueCollision{Index: "name", Value: "cafe", RowIDs: []pg2mysql.RowKey{{"3"}, {"4"}}, RowCount: 2},
+					pg2mysql.UniqueCollision{Index: "name", Value: "foo", RowIDs: []pg2mysql.RowKey{{"1"}, {"2"}}, RowCount: 2},
+					pg2mysql.UniqueCollision{Index: "code_key", Value: "a", RowIDs: []pg2mysql.RowKey{{"1"}, {"2"}}, RowCount: 2},
+				))
+			})
+		})
+
+		Context("when rows reference missing rows through a foreign key in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE fk_parents (id integer PRIMARY KEY);
+				CREATE TABLE fk_children (id integer PRIMARY KEY, parent_id integer)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE fk_parents (id integer PRIMARY KEY)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(`CREATE TABLE fk_children (
+					id integer PRIMARY KEY,
+					parent_id integer,
+					CONSTRAINT fk_children_parent FOREIGN KEY (parent_id) REFERENCES fk_parents (id)
+				)`)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec(`
+				INSERT INTO fk_parents VALUES (1);
+				INSERT INTO fk_children VALUES (1, 1), (2, 2), (3, NULL), (4, 3)`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE fk_children; DROP TABLE fk_parents")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE fk_children")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE fk_parents")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the rows that would violate each constraint", func() {
+				results, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var result pg2mysql.ValidationResult
+				for _, r := range results {
+					if r.TableName == "fk_children" {
+						result = r
+					}
+				}
+
+				Expect(result.ForeignKeyViolations).To(Equal([]pg2mysql.ForeignKeyViolation{
+					{
+						Constraint:      "fk_children_parent",
+						ReferencedTable: "fk_parents",
+						RowIDs:          []pg2mysql.RowKey{{"2"}, {"4"}},
+						RowCount:        2,
+					},
+				}))
+			})
+		})
+
+		Context("when rows reference string keys that only match under the collation in MySQL", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec(`
+				CREATE TABLE fk_ci_parents (code text PRIMARY KEY);
+				CREATE TABLE fk_ci_children (id integer PRIMARY KEY, parent_code text)`)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE fk_ci_parents (code varchar(20) COLLATE utf8mb4_general_ci PRIMARY KEY)")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec(`CREATE TABLE fk_ci_children (
+					id integer PRIMARY KEY,
+					parent_code varchar(20) COLLATE utf8mb4_general_ci,
+					CONSTRAINT fk_ci_children_parent FOREIGN KEY (parent_code) REFERENCES fk_ci_parents (code)
+				)`)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec(`
+				INSERT INTO fk_ci_parents VALUES ('Foo');
+				INSERT INTO fk_ci_children VALUES (1, 'Foo'), (2, 'foo'), (3, 'FOO '), (4, 'bar')`)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE fk_ci_children; DROP TABLE fk_ci_parents")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE fk_ci_children")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE fk_ci_parents")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("only reports the rows without a match under the collation", func() {
+				results, err := validator.Validate()
+				Expect(err).NotTo(HaveOccurred())
+
+				var result pg2mysql.ValidationResult
+				for _, r := range results {
+					if r.TableName == "fk_ci_children" {
+						result = r
+					}
+				}
+
+				Expect(result.ForeignKeyViolations).To(Equal([]pg2mysql.ForeignKeyViolation{
+					{
+						Constraint:      "fk_ci_children_parent",
+						ReferencedTable: "fk_ci_parents",
+						RowIDs:          []pg2mysql.RowKey{{"4"}},
+						RowCount:        1,
+					},
 				}))
 			})
 		})
@@ -123,13 +535,15 @@ var _ = Describe("Validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(HaveLen(3))
 				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
-					TableName: "table_with_id",
+					TableName:     "table_with_id",
+					UnsafeColumns: createdAtIsLossy,
 				}))
 
 				Expect(result).To(ContainElement(pg2mysql.ValidationResult{
 					TableName:            "table_without_id",
 					IncompatibleRowIDs:   nil,
 					IncompatibleRowCount: 1,
+					UnsafeColumns:        createdAtIsLossy,
 				}))
 			})
 		})
@@ -0,0 +1,262 @@
+package pg2mysql
+
+import "fmt"
+
+// InvalidColumn describes the rows of a table whose values in Column cannot
+// be stored in MySQL as they are. RowIDs is only set for tables with a key.
+type InvalidColumn struct {
+	Column   string
+	Reason   string
+	RowIDs   []RowKey
+	RowCount int64
+}
+
+func (c InvalidColumn) String() string {
+	if len(c.RowIDs) > 0 {
+		return fmt.Sprintf("%s %s (%d rows with IDs %v)", c.Column, c.Reason, c.RowCount, c.RowIDs)
+	}
+	return fmt.Sprintf("%s %s (%d rows)", c.Column, c.Reason, c.RowCount)
+}
+
+// valueCheck is a PostgreSQL condition that matches the rows whose value
+// in column cannot be stored in MySQL as it is.
+type valueCheck struct {
+	column    string
+	reason    string
+	condition string
+}
+
+// GetInvalidColumns checks the values of each column of src that cannot
+// always be stored in the matching column of dst, and returns the columns
+// for which rows were found.
+func GetInvalidColumns(db DB, src, dst *Table) ([]InvalidColumn, error) {
+	var checks []valueCheck
+	for _, srcColumn := range src.Columns {
+		_, dstColumn, err := dst.GetColumn(srcColumn.Name)
+		if err != nil {
+			continue
+		}
+
+		checks = append(checks, nullChecks(srcColumn, dstColumn)...)
+		checks = append(checks, numericChecks(srcColumn, dstColumn)...)
+		checks = append(checks, datetimeChecks(srcColumn, dstColumn)...)
+		checks = append(checks, charsetChecks(srcColumn, dstColumn)...)
+	}
+
+	var invalid []InvalidColumn
+	for _, check := range checks {
+		column := InvalidColumn{
+			Column: check.column,
+			Reason: check.reason,
+		}
+
+		if src.HasKey() {
+			rowIDs, err := selectRowKeys(db, src, check.condition)
+			if err != nil {
+				return nil, fmt.Errorf("failed checking values of %s: %s", check.column, err)
+			}
+			column.RowIDs = rowIDs
+			column.RowCount = int64(len(rowIDs))
+		} else {
+			stmt := fmt.Sprintf("SELECT count(1) FROM %s WHERE %s", src.Name, check.condition)
+			if err := db.Querier().QueryRow(stmt).Scan(&column.RowCount); err != nil {
+				return nil, fmt.Errorf("failed checking values of %s: %s", check.column, err)
+			}
+		}
+
+		if column.RowCount > 0 {
+			invalid = append(invalid, column)
+		}
+	}
+
+	return invalid, nil
+}
+
+// nullChecks returns the check for NULLs in a nullable column that is NOT
+// NULL in MySQL, which fail to insert in strict mode and are replaced by
+// zero or an empty value otherwise.
+func nullChecks(src, dst *Column) []valueCheck {
+	if !src.Nullable || dst.Nullable {
+		return nil
+	}
+
+	return []valueCheck{{
+		column:    src.Name,
+		reason:    "has NULL values, but is NOT NULL in MySQL",
+		condition: fmt.Sprintf("%s IS NULL", src.Name),
+	}}
+}
+
+var numericFamilies = map[string]bool{
+	"integer": true,
+	"decimal": true,
+	"float":   true,
+}
+
+// numericChecks returns the checks for values of a numeric column that are
+// out of range for, or would lose precision in, a narrower numeric column.
+func numericChecks(src, dst *Column) []valueCheck {
+	srcFamily, dstFamily := typeFamilies[src.Type], typeFamilies[dst.Type]
+	if !numericFamilies[srcFamily] || !numericFamilies[dstFamily] || src.Type == "boolean" {
+		return nil
+	}
+
+	if src.CompatibilityWith(dst) == Safe {
+		return nil
+	}
+
+	value := src.Name
+	if src.Type == "money" {
+		value += "::numeric"
+	}
+
+	dstType := describeType(dst)
+	outOfRange := func(condition string) valueCheck {
+		return valueCheck{
+			column:    src.Name,
+			reason:    fmt.Sprintf("has values out of range for %s", dstType),
+			condition: condition,
+		}
+	}
+	losesPrecision := func(condition string) valueCheck {
+		return valueCheck{
+			column:    src.Name,
+			reason:    fmt.Sprintf("has values that would lose precision in %s", dstType),
+			condition: condition,
+		}
+	}
+
+	var checks []valueCheck
+	switch dstFamily {
+	case "integer":
+		bits, ok := integerBits[dst.Type]
+		if !ok {
+			return nil
+		}
+
+		var min, max string
+		if dst.Unsigned {
+			min, max = "0", fmt.Sprintf("%d", uint64(1)<<uint(bits)-1)
+		} else {
+			min, max = fmt.Sprintf("%d", -(int64(1)<<uint(bits-1))), fmt.Sprintf("%d", int64(1)<<uint(bits-1)-1)
+		}
+
+		checks = append(checks, outOfRange(fmt.Sprintf("%s < %s OR %s > %s", value, min, value, max)))
+		if srcFamily != "integer" {
+			checks = append(checks, losesPrecision(fmt.Sprintf("%s <> trunc(%s)", value, value)))
+		}
+
+	case "decimal":
+		// values out of range are only reported as such, which also keeps
+		// infinite floating point values away from the cast to numeric
+		limit := fmt.Sprintf("1e%d", dst.NumericPrecision-dst.NumericScale)
+		checks = append(checks, outOfRange(fmt.Sprintf("abs(%s) >= %s", value, limit)))
+		if srcFamily != "integer" {
+			checks = append(checks, losesPrecision(fmt.Sprintf(
+				"CASE WHEN abs(%s) >= %s THEN false ELSE %s::numeric <> round(%s::numeric, %d) END",
+				value, limit, value, value, dst.NumericScale,
+			)))
+		}
+
+	case "float":
+		// casting to a narrower floating point type fails when the value is
+		// out of range, so the range is checked first
+		limit, cast := "1.7976931348623157e308", "double precision"
+		if floatBits[dst.Type] < 53 {
+			limit, cast = "3.4028234e38", "real"
+		}
+
+		checks = append(checks,
+			outOfRange(fmt.Sprintf("abs(%s) > %s", value, limit)),
+			losesPrecision(fmt.Sprintf(
+				"CASE WHEN abs(%s) > %s THEN false ELSE %s::numeric <> %s::%s::numeric END",
+				value, limit, value, value, cast,
+			)),
+		)
+	}
+
+	if dst.Unsigned && dstFamily != "integer" {
+		checks = append(checks, outOfRange(fmt.Sprintf("%s < 0", value)))
+	}
+
+	return checks
+}
+
+// datetimeRanges holds the range of each MySQL date and time type. The range
+// of timestamp is in UTC.
+var datetimeRanges = map[string][2]string{
+	"date":      {"1000-01-01", "9999-12-31 23:59:59.999999"},
+	"datetime":  {"1000-01-01", "9999-12-31 23:59:59.999999"},
+	"timestamp": {"1970-01-01 00:00:01", "2038-01-19 03:14:07.999999"},
+}
+
+// datetimeChecks returns the checks for infinite dates and times, and for
+// those outside the range of the MySQL type, such as BC dates.
+func datetimeChecks(src, dst *Column) []valueCheck {
+	switch typeFamilies[src.Type] {
+	case "datetime", "date":
+	default:
+		return nil
+	}
+
+	bounds, ok := datetimeRanges[dst.Type]
+	if !ok {
+		return nil
+	}
+
+	// compare times with a time zone as UTC, since that is how the range of
+	// a MySQL timestamp is defined
+	value := fmt.Sprintf("%s::timestamp", src.Name)
+	if src.Type == "timestamp with time zone" {
+		value = fmt.Sprintf("(%s AT TIME ZONE 'UTC')", src.Name)
+	}
+
+	return []valueCheck{
+		{
+			column:    src.Name,
+			reason:    "has infinite values",
+			condition: fmt.Sprintf("NOT isfinite(%s)", src.Name),
+		},
+		{
+			column: src.Name,
+			reason: fmt.Sprintf("has values out of range for %s", dst.Type),
+			condition: fmt.Sprintf(
+				"isfinite(%s) AND (%s < '%s' OR %s > '%s')",
+				src.Name, value, bounds[0], value, bounds[1],
+			),
+		},
+	}
+}
+
+// charsetPatterns holds, for the MySQL character sets that cannot hold
+// every Unicode character, a PostgreSQL regular expression matching any
+// character that the character set cannot represent.
+var charsetPatterns = map[string]string{
+	// utf8 is limited to 3 bytes per character, as is ucs2 to 2
+	"utf8":    `[\U00010000-\U0010FFFF]`,
+	"utf8mb3": `[\U00010000-\U0010FFFF]`,
+	"ucs2":    `[\U00010000-\U0010FFFF]`,
+
+	"ascii": `[^\u0001-\u007F]`,
+
+	// MySQL's latin1 is cp1252, with the five bytes that cp1252 leaves
+	// undefined mapped to the equivalent control characters
+	"latin1": `[^\u0001-\u007F\u00A0-\u00FF\u0081\u008D\u008F\u0090\u009D` +
+		`\u20AC\u201A\u0192\u201E\u2026\u2020\u2021\u02C6\u2030\u0160\u2039\u0152\u017D` +
+		`\u2018\u2019\u201C\u201D\u2022\u2013\u2014\u02DC\u2122\u0161\u203A\u0153\u017E\u0178]`,
+}
+
+// charsetChecks returns the check for values with characters that the
+// character set of the MySQL column cannot represent.
+func charsetChecks(src, dst *Column) []valueCheck {
+	pattern, ok := charsetPatterns[dst.Charset]
+	if !ok {
+		return nil
+	}
+
+	return []valueCheck{{
+		column:    src.Name,
+		reason:    fmt.Sprintf("has characters that %s cannot represent", dst.Charset),
+		condition: fmt.Sprintf("%s::text ~ '%s'", src.Name, pattern),
+	}}
+}
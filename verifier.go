@@ -20,24 +20,50 @@ func NewVerifier(src, dst DB, watcher VerifierWatcher) Verifier {
 }
 
 func (v *verifier) Verify() error {
+	err := v.src.BeginSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to begin snapshot: %s", err)
+	}
+	defer v.src.EndSnapshot()
+
 	srcSchema, err := BuildSchema(v.src)
 	if err != nil {
 		return fmt.Errorf("failed to build source schema: %s", err)
 	}
 
+	dstSchema, err := BuildSchema(v.dst)
+	if err != nil {
+		return fmt.Errorf("failed to build destination schema: %s", err)
+	}
+
 	for _, table := range srcSchema.Tables {
 		v.watcher.TableVerificationDidStart(table.Name)
 
 		var missingRows int64
 		var missingIDs []string
-		err = EachMissingRow(v.src, v.dst, table, func(scanArgs []interface{}) {
-			if colIndex, _, getColErr := table.GetColumn("id"); getColErr == nil {
-				if colID, ok := scanArgs[colIndex].(*interface{}); ok {
-					missingIDs = append(missingIDs, fmt.Sprintf("%v", *colID))
+
+		// tables with a key are compared key by key; the rows of other
+		// tables are counted by hash, which holds a count for every row
+		// of the table in dst in memory
+		if parts, ok := keyParts(table); ok {
+			err = eachMissingKey(v.src, v.dst, table, parts, func(key []interface{}) {
+				missingIDs = append(missingIDs, NewRowKey(key).String())
+				missingRows++
+			})
+		} else {
+			keyIndexes, _, _ := table.GetKeyColumns()
+			keyValues := make([]interface{}, len(keyIndexes))
+
+			err = eachMissingRow(v.src, v.dst, table, dstSchema.Tables[table.Name], nil, func(scanArgs []interface{}) {
+				if len(keyIndexes) > 0 {
+					for i, index := range keyIndexes {
+						keyValues[i] = scanArgs[index]
+					}
+					missingIDs = append(missingIDs, NewRowKey(keyValues).String())
 				}
-			}
-			missingRows++
-		})
+				missingRows++
+			})
+		}
 		if err != nil {
 			v.watcher.TableVerificationDidFinishWithError(table.Name, err)
 			continue
@@ -25,6 +25,10 @@ var _ = Describe("Verifier", func() {
 			"",
 			"127.0.0.1",
 			3306,
+			"",
+			"",
+			nil,
+			pg2mysql.TableFilter{},
 		)
 
 		err := mysql.Open()
@@ -37,6 +41,8 @@ var _ = Describe("Verifier", func() {
 			"127.0.0.1",
 			5432,
 			"disable",
+			nil,
+			pg2mysql.TableFilter{},
 		)
 		err = pg.Open()
 		Expect(err).NotTo(HaveOccurred())
@@ -94,6 +100,40 @@ var _ = Describe("Verifier", func() {
 			})
 		})
 
+		Context("when there is data in postgres that is not in mysql in a table with a composite key", func() {
+			BeforeEach(func() {
+				_, err := pgRunner.DB().Exec("CREATE TABLE table_with_composite_key (space_id integer NOT NULL, user_guid varchar(36) NOT NULL, PRIMARY KEY (space_id, user_guid))")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("CREATE TABLE table_with_composite_key (space_id integer NOT NULL, user_guid varchar(36) NOT NULL, PRIMARY KEY (space_id, user_guid))")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = pgRunner.DB().Exec("INSERT INTO table_with_composite_key VALUES (1, 'a'), (1, 'b')")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("INSERT INTO table_with_composite_key VALUES (1, 'a')")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := pgRunner.DB().Exec("DROP TABLE table_with_composite_key")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = mysqlRunner.DB().Exec("DROP TABLE table_with_composite_key")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("reports the keys of the missing rows", func() {
+				err := verifier.Verify()
+				Expect(err).NotTo(HaveOccurred())
+
+				for i := 0; i < watcher.TableVerificationDidFinishCallCount(); i++ {
+					tableName, missingRows, missingIDs := watcher.TableVerificationDidFinishArgsForCall(i)
+					if tableName == "table_with_composite_key" {
+						Expect(missingRows).To(Equal(int64(1)))
+						Expect(missingIDs).To(Equal([]string{"(1, b)"}))
+					}
+				}
+			})
+		})
+
 		Context("when there is data in postgres that is in mysql", func() {
 			BeforeEach(func() {
 				id := 3
@@ -3,6 +3,7 @@ package pg2mysql
 import (
 	"fmt"
 	"strings"
+	"sync"
 )
 
 //go:generate counterfeiter . VerifierWatcher
@@ -26,21 +27,41 @@ type MigratorWatcher interface {
 	EnableConstraintsDidFinish()
 	EnableConstraintsDidFailWithError(err error)
 
+	DidFindForeignKeyCycle(tableNames []string)
+
 	WillTruncateTable(tableName string)
 	TruncateTableDidFinish(tableName string)
 
 	TableMigrationDidStart(tableName string)
 	TableMigrationDidFinish(tableName string, recordsInserted int64)
+	TableChunkMigrationDidFinish(tableName string, chunk int, chunks int, recordsInserted int64)
+	DidSkipCompletedTable(tableName string)
 
 	DidMigrateRow(tableName string)
 	DidFailToMigrateRowWithError(tableName string, err error)
+
+	DidResetAutoIncrement(tableName string, autoIncrement int64)
+	DidMigrateSequence(sequenceName string)
 }
 
 func NewStdoutPrinter() *StdoutPrinter {
 	return &StdoutPrinter{}
 }
 
-type StdoutPrinter struct{}
+// NewTaggedStdoutPrinter returns a printer that writes each table event as
+// a complete line prefixed with the table name, which keeps the output
+// readable when several tables are migrated at once.
+func NewTaggedStdoutPrinter() *StdoutPrinter {
+	return &StdoutPrinter{tagged: true}
+}
+
+type StdoutPrinter struct {
+	tagged bool
+}
+
+func (s *StdoutPrinter) tableLine(tableName, format string, args ...interface{}) {
+	fmt.Printf("[%s] %s\n", tableName, fmt.Sprintf(format, args...))
+}
 
 func (s *StdoutPrinter) TableVerificationDidStart(tableName string) {
 	fmt.Printf("Verifying table %s...", tableName)
@@ -101,19 +122,46 @@ func (s *StdoutPrinter) EnableConstraintsDidFinish() {
 	s.done()
 }
 
+func (s *StdoutPrinter) DidFindForeignKeyCycle(tableNames []string) {
+	if s.tagged {
+		for _, tableName := range tableNames {
+			s.tableLine(tableName, "Foreign keys form a cycle, migrating with foreign key checks disabled")
+		}
+		return
+	}
+	fmt.Printf("Foreign keys form a cycle between tables %s, migrating them with foreign key checks disabled\n", strings.Join(tableNames, ", "))
+}
+
 func (s *StdoutPrinter) WillTruncateTable(tableName string) {
+	if s.tagged {
+		s.tableLine(tableName, "Truncating...")
+		return
+	}
 	fmt.Printf("Truncating %s...", tableName)
 }
 
 func (s *StdoutPrinter) TruncateTableDidFinish(tableName string) {
+	if s.tagged {
+		s.tableLine(tableName, "Truncated")
+		return
+	}
 	s.done()
 }
 
 func (s *StdoutPrinter) TableMigrationDidStart(tableName string) {
+	if s.tagged {
+		s.tableLine(tableName, "Migrating...")
+		return
+	}
 	fmt.Printf("Migrating %s...", tableName)
 }
 
 func (s *StdoutPrinter) TableMigrationDidFinish(tableName string, recordsInserted int64) {
+	if s.tagged {
+		s.tableLine(tableName, "OK (%d records inserted)", recordsInserted)
+		return
+	}
+
 	switch recordsInserted {
 	case 0:
 		fmt.Println("OK (0 records inserted)")
@@ -124,10 +172,164 @@ func (s *StdoutPrinter) TableMigrationDidFinish(tableName string, recordsInserte
 	}
 }
 
+func (s *StdoutPrinter) TableChunkMigrationDidFinish(tableName string, chunk int, chunks int, recordsInserted int64) {
+	if s.tagged {
+		s.tableLine(tableName, "chunk %d/%d OK (%d records inserted)", chunk, chunks, recordsInserted)
+		return
+	}
+	fmt.Printf(".")
+}
+
+func (s *StdoutPrinter) DidSkipCompletedTable(tableName string) {
+	if s.tagged {
+		s.tableLine(tableName, "Skipped (already migrated)")
+		return
+	}
+	fmt.Printf("Skipping %s (already migrated)\n", tableName)
+}
+
 func (s *StdoutPrinter) DidMigrateRow(tableName string) {
+	if s.tagged {
+		return
+	}
 	fmt.Printf(".")
 }
 
 func (s *StdoutPrinter) DidFailToMigrateRowWithError(tableName string, err error) {
+	if s.tagged {
+		s.tableLine(tableName, "failed to migrate row: %s", err)
+		return
+	}
 	fmt.Printf("x")
 }
+
+func (s *StdoutPrinter) DidResetAutoIncrement(tableName string, autoIncrement int64) {
+	if s.tagged {
+		s.tableLine(tableName, "AUTO_INCREMENT set to %d", autoIncrement)
+		return
+	}
+	fmt.Printf("Set AUTO_INCREMENT of %s to %d\n", tableName, autoIncrement)
+}
+
+func (s *StdoutPrinter) DidMigrateSequence(sequenceName string) {
+	if s.tagged {
+		s.tableLine(sequenceName, "Migrated sequence")
+		return
+	}
+	fmt.Printf("Migrated sequence %s\n", sequenceName)
+}
+
+// synchronizedMigratorWatcher serializes calls to a MigratorWatcher that is
+// shared between workers.
+type synchronizedMigratorWatcher struct {
+	mu      sync.Mutex
+	watcher MigratorWatcher
+}
+
+func (s *synchronizedMigratorWatcher) WillBuildSchema() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.WillBuildSchema()
+}
+
+func (s *synchronizedMigratorWatcher) DidBuildSchema() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidBuildSchema()
+}
+
+func (s *synchronizedMigratorWatcher) WillDisableConstraints() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.WillDisableConstraints()
+}
+
+func (s *synchronizedMigratorWatcher) DidDisableConstraints() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidDisableConstraints()
+}
+
+func (s *synchronizedMigratorWatcher) WillEnableConstraints() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.WillEnableConstraints()
+}
+
+func (s *synchronizedMigratorWatcher) EnableConstraintsDidFinish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.EnableConstraintsDidFinish()
+}
+
+func (s *synchronizedMigratorWatcher) EnableConstraintsDidFailWithError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.EnableConstraintsDidFailWithError(err)
+}
+
+func (s *synchronizedMigratorWatcher) DidFindForeignKeyCycle(tableNames []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidFindForeignKeyCycle(tableNames)
+}
+
+func (s *synchronizedMigratorWatcher) WillTruncateTable(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.WillTruncateTable(tableName)
+}
+
+func (s *synchronizedMigratorWatcher) TruncateTableDidFinish(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.TruncateTableDidFinish(tableName)
+}
+
+func (s *synchronizedMigratorWatcher) TableMigrationDidStart(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.TableMigrationDidStart(tableName)
+}
+
+func (s *synchronizedMigratorWatcher) TableMigrationDidFinish(tableName string, recordsInserted int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.TableMigrationDidFinish(tableName, recordsInserted)
+}
+
+func (s *synchronizedMigratorWatcher) TableChunkMigrationDidFinish(tableName string, chunk int, chunks int, recordsInserted int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.TableChunkMigrationDidFinish(tableName, chunk, chunks, recordsInserted)
+}
+
+func (s *synchronizedMigratorWatcher) DidSkipCompletedTable(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidSkipCompletedTable(tableName)
+}
+
+func (s *synchronizedMigratorWatcher) DidMigrateRow(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidMigrateRow(tableName)
+}
+
+func (s *synchronizedMigratorWatcher) DidFailToMigrateRowWithError(tableName string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidFailToMigrateRowWithError(tableName, err)
+}
+
+func (s *synchronizedMigratorWatcher) DidResetAutoIncrement(tableName string, autoIncrement int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidResetAutoIncrement(tableName, autoIncrement)
+}
+
+func (s *synchronizedMigratorWatcher) DidMigrateSequence(sequenceName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcher.DidMigrateSequence(sequenceName)
+}